@@ -7,15 +7,17 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
 	"github.com/tamirdavid/paralix/lib/logger"
 	osutils "github.com/tamirdavid/paralix/lib/osUtils"
 	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var commandCmd = &cobra.Command{
@@ -27,42 +29,200 @@ var commandCmd = &cobra.Command{
 		if inputValidationError != nil {
 			return inputValidationError
 		}
-		outputResourcesError := handleOutputfile()
-		if outputResourcesError != nil {
-			return outputResourcesError
+		jobs, buildJobsError := buildJobs()
+		if buildJobsError != nil {
+			return buildJobsError
 		}
-		executeErr := executeParallel()
-		if executeErr != nil {
-			return executeErr
-		}
-		writeResultsErr := writeResultstoFile()
-		if writeResultsErr != nil {
-			return writeResultsErr
-		}
-		return nil
+		return runJobs(cmd, jobs)
 	},
 }
 
 var command string
 var placeholders string
 var filepathInput string
+var skipBlankLines bool
+var skipComments bool
+var commentPrefix string
+var trimWhitespace bool
+var dedupeValues bool
+var inputJSON string
+var inputJSONL string
+var valuesFromOutput string
+var selectExpression string
+var succeededOnly bool
 var outputfile string
+var dryRun bool
+var outputFormat string
+var askConfirmation bool
+var confirmThreshold int
+var assumeYes bool
+var showProgress bool
+var jobTimeout time.Duration
+var jobRetries int
+var showSummary bool
+var slowestCount int
+var parallelJobs int
+var jobLogPath string
+var resume bool
+var resumeFailed bool
+var haltPolicyInput string
+var haltPolicy jobutils.HaltPolicy
+var startRateInput string
+var startRatePerSecond float64
+var startRateBurst int
+var startDelay time.Duration
+var maxLoad float64
+var minFreeMemoryInput string
+var minFreeMemory uint64
+var jobMemoryInput string
+var jobLimits osutils.JobLimits
+var cgroupParent string
 var outputfilesDir string = "/tmp/paralix_output/"
 
 func init() {
 	rootCmd.AddCommand(commandCmd)
-	commandCmd.Flags().StringVarP(&command, "execute", "e", "", "Command to execute with placeholders (<KEY>) [Example: --execute 'echo <WHAT_SHOULD_ECHO>']")
-	commandCmd.Flags().StringVarP(&placeholders, "placeholder", "p", "", "Placeholders in the format of 'KEY={VALUE1,VALUE2,VALUE3}' [Example -p 'WHAT_SHOULD_ECHO={HELLO,WORLD]'")
-	commandCmd.Flags().StringVarP(&filepathInput, "inputfile", "f", "", "File that contain the inputs to run, each input in a new line' [Example -f 'customers']")
-	commandCmd.Flags().StringVarP(&outputfile, "output", "o", "", "Output file that the results for the command will be written in")
+	addRunFlags(commandCmd.Flags())
+	addExecutorFlags(commandCmd.Flags())
+	addRemoteFlags(commandCmd.Flags())
+	addJobLimitFlags(commandCmd.Flags())
 	commandCmd.MarkFlagRequired("output")
 	commandCmd.MarkFlagRequired("execute")
 }
 
-func writeResultstoFile() error {
-	files, err := filepath.Glob(filepath.Join(outputfilesDir, "*"))
-	if err != nil {
-		return err
+// addRunFlags defines the flags of the jobs and of the run, shared by paralix command and paralix serve
+func addRunFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&command, "execute", "e", "", "Command to execute with placeholders (<KEY>) [Example: --execute 'echo <WHAT_SHOULD_ECHO>']")
+	flags.StringVarP(&placeholders, "placeholder", "p", "", "Placeholders in the format of 'KEY={VALUE1,VALUE2,VALUE3}' [Example -p 'WHAT_SHOULD_ECHO={HELLO,WORLD}'], values can be quoted or escaped and several KEY={...} groups run every combination")
+	flags.StringVarP(&filepathInput, "inputfile", "f", "", "File that contain the inputs to run, each input in a new line' [Example -f 'customers']")
+	flags.BoolVar(&skipBlankLines, "skip-blank-lines", false, "Ignore blank lines in the --inputfile [-f]")
+	flags.BoolVar(&skipComments, "skip-comments", false, "Ignore comment lines in the --inputfile [-f]")
+	flags.StringVar(&commentPrefix, "comment-prefix", "#", "Prefix of the comment lines ignored by --skip-comments")
+	flags.BoolVar(&trimWhitespace, "trim", false, "Trim leading and trailing whitespace of the --inputfile [-f] lines")
+	flags.BoolVar(&dedupeValues, "dedupe", false, "Run each value of the --inputfile [-f] only once")
+	flags.StringVar(&inputJSON, "input-json", "", "JSON file holding an array of objects, each object is a job and its fields fill the placeholders [Example --input-json services.json -e 'echo <meta.region>']")
+	flags.StringVar(&inputJSONL, "input-jsonl", "", "JSON Lines file, each object is a job and its fields fill the placeholders [Example --input-jsonl services.jsonl]")
+	flags.StringVar(&valuesFromOutput, "values-from-output", "", "json or jsonl output file of a previous run, the values picked by --select fill the single placeholder of the command [Example --values-from-output prev.jsonl]")
+	flags.StringVar(&selectExpression, "select", ".stdout | lines", "What --values-from-output takes from every job: .stdout, .command, .host, .error or .values.KEY, optionally piped to lines and trim")
+	flags.BoolVar(&succeededOnly, "succeeded-only", false, "Take the --values-from-output values only from the jobs that succeeded")
+	flags.StringVarP(&outputfile, "output", "o", "", "Output file that the results for the command will be written in")
+	flags.BoolVar(&dryRun, "dry-run", false, "Validate and print the jobs that would run without executing them")
+	flags.StringVar(&outputFormat, "format", "text", "Format of the output file and of the --dry-run listing [text, json, jsonl]")
+	flags.BoolVar(&askConfirmation, "confirm", false, "Show the jobs that are about to run and ask for confirmation before running them")
+	flags.IntVar(&confirmThreshold, "confirm-threshold", 100, "Ask for confirmation when more jobs than this are about to run, 0 disables it")
+	flags.BoolVarP(&assumeYes, "yes", "y", false, "Run without asking for confirmation (for CI)")
+	flags.BoolVar(&showProgress, "progress", false, "Show the jobs progress and ETA on stderr")
+	flags.DurationVar(&jobTimeout, "timeout", 0, "Kill a job that runs longer than this, 0 means no timeout [Example --timeout 30s]")
+	flags.IntVar(&jobRetries, "retries", 0, "Run a failed job up to this many more times, a timed out job is retried too")
+	flags.BoolVar(&showSummary, "summary", true, "Print a summary of the run with timing statistics on stderr")
+	flags.IntVar(&slowestCount, "slowest", 5, "Number of slowest jobs listed in the summary")
+	flags.IntVarP(&parallelJobs, "jobs", "j", 0, "Number of jobs to run at once, 0 runs all of them at once")
+	flags.StringVar(&jobLogPath, "joblog", "", "Log every finished job as a tab separated line (seq, host, slot, start time, runtime, exit code, signal, command) to this file")
+	flags.BoolVar(&resume, "resume", false, "Skip the jobs that already finished according to the --joblog of a previous run")
+	flags.BoolVar(&resumeFailed, "resume-failed", false, "Run only the jobs that failed or didn't run according to the --joblog of a previous run")
+	flags.StringVar(&haltPolicyInput, "halt", "never", "When to stop the run: never, or when,condition=value where when is soon (wait for running jobs) or now (kill them) and condition is fail/success/done=N or N% [Example --halt now,fail=1]")
+	flags.StringVar(&startRateInput, "rate", "", "Maximal rate of job starts, regardless of --jobs [Example --rate 10/s, also N/m and N/h]")
+	flags.IntVar(&startRateBurst, "rate-burst", 1, "Number of jobs --rate lets start at once after an idle period")
+	flags.DurationVar(&startDelay, "delay", 0, "Minimal delay between two job starts [Example --delay 200ms]")
+	flags.Float64Var(&maxLoad, "max-load", 0, "Don't start new jobs while the 1 minute load average is above this [Example --max-load 8]")
+	flags.StringVar(&minFreeMemoryInput, "min-free-mem", "", "Don't start new jobs while the available memory is below this [Example --min-free-mem 2G]")
+}
+
+func addJobLimitFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&jobMemoryInput, "job-memory", "", "Memory limit of every job, enforced with cgroup v2 when available and with rlimits otherwise [Example --job-memory 512M]")
+	flags.Float64Var(&jobLimits.CPU, "job-cpu", 0, "CPU limit of every job in cores, needs cgroup v2 [Example --job-cpu 0.5]")
+	flags.Uint64Var(&jobLimits.NoFile, "job-nofile", 0, "Limit of open files of every job [Example --job-nofile 1024]")
+	flags.StringVar(&cgroupParent, "cgroup-parent", "", "Delegated cgroup v2 directory to create the jobs cgroups in, by default the cgroup of paralix")
+}
+
+type job struct {
+	index    int
+	template string
+	keys     []string
+	values   map[string]string
+	label    string
+	// indexes of the jobs that must succeed before this one starts
+	needs []int
+	// completes the job once the jobs it needs finished, like filling the values it takes from them
+	prepare func(j job) (job, error)
+}
+
+func newJob(index int, values map[string]string, keys []string) job {
+	// the label is written above the job output in the output file
+	labelParts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		labelParts = append(labelParts, values[key])
+	}
+	return job{index: index, template: command, keys: keys, values: values, label: strings.Join(labelParts, " ")}
+}
+
+func (j job) render(template string) string {
+	return paralixutils.RenderPlaceholders(template, j.values)
+}
+
+func (j job) renderCommand() string {
+	return j.render(j.template)
+}
+
+func (j job) outputFilePath() string {
+	return filepath.Join(outputfilesDir, fmt.Sprintf("%06d", j.index))
+}
+
+func runJobs(cmd *cobra.Command, jobs []job) error {
+	// the jobs of every input method and of the spec files go through the same steps
+	if dryRun {
+		return printDryRun(jobs)
+	}
+	confirmationError := confirmRun(jobs)
+	if confirmationError != nil {
+		return confirmationError
+	}
+	outputResourcesError := handleOutputfile()
+	if outputResourcesError != nil {
+		return outputResourcesError
+	}
+	jobsToRun, previousResults, resumeError := splitResumedJobs(jobs)
+	if resumeError != nil {
+		return resumeError
+	}
+	startTime := time.Now()
+	executedResults, executeErr := executeParallel(jobsToRun)
+	var haltErr *haltError
+	if executeErr != nil && !errors.As(executeErr, &haltErr) {
+		return executeErr
+	}
+	results := mergeResumedResults(jobs, executedResults, previousResults)
+	summary := jobutils.Summarize(results, time.Since(startTime), slowestCount)
+	writeResultsErr := writeResultstoFile(jobs, results, summary)
+	if writeResultsErr != nil {
+		return writeResultsErr
+	}
+	if showSummary {
+		summary.WriteTable(os.Stderr)
+	}
+	if haltErr != nil {
+		cmd.SilenceUsage = true
+		return haltErr.exitError()
+	}
+	// the tasks of a DAG build on each other, the run failed unless every one of them succeeded
+	if hasDependencies(jobs) && (summary.Failed > 0 || summary.Skipped > 0) {
+		cmd.SilenceUsage = true
+		return &exitCodeError{code: 1, err: fmt.Errorf("%d of %d jobs failed and %d were skipped", summary.Failed, summary.Total, summary.Skipped)}
+	}
+	return nil
+}
+
+func hasDependencies(jobs []job) bool {
+	for _, j := range jobs {
+		if len(j.needs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func writeResultstoFile(jobs []job, results []jobutils.Result, summary jobutils.Summary) error {
+	if outputFormat != "text" {
+		return writeStructuredResultsToFile(jobs, results, summary)
 	}
 	// Concatenate the files and write the result to the output file
 	output, creationFileError := osutils.CreateFile(outputfile)
@@ -71,12 +231,56 @@ func writeResultstoFile() error {
 	}
 	defer output.Close()
 
-	readWriteError := osutils.ReadFilesContentAndWriteToOneFile(output, files)
-	if readWriteError != nil {
-		return readWriteError
+	for i, j := range jobs {
+		// jobs that never ran have no output, their header is followed by the reason instead
+		if results[i].Skipped() {
+			fmt.Fprintf(output, "%s\nskipped: %s\n\n", j.label, results[i].SkipReason)
+			continue
+		}
+		readWriteError := osutils.WriteFilesContentWithHeadersToOneFile(output, []string{j.label}, []string{j.outputFilePath()})
+		if readWriteError != nil {
+			return readWriteError
+		}
+	}
+	osutils.PrintFileContent(outputfile)
+	if jobLogPath == "" {
+		osutils.RemoveDirectory(outputfilesDir)
+	}
+	return nil
+}
+
+func writeStructuredResultsToFile(jobs []job, results []jobutils.Result, summary jobutils.Summary) error {
+	records := make([]jobutils.ResultRecord, 0, len(jobs))
+	for i, j := range jobs {
+		var stdout []byte
+		// a skipped job never wrote its output file, the file there may be left over from a resumed run
+		if !results[i].Skipped() {
+			var err error
+			if stdout, err = os.ReadFile(j.outputFilePath()); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		records = append(records, jobutils.NewResultRecord(results[i], string(stdout)))
+	}
+	output, creationFileError := osutils.CreateFile(outputfile)
+	if creationFileError != nil {
+		return creationFileError
+	}
+	defer output.Close()
+
+	var writeError error
+	if outputFormat == "json" {
+		writeError = jobutils.WriteResultsJSON(output, records, summary)
+	} else {
+		writeError = jobutils.WriteResultsJSONLines(output, records, summary)
+	}
+	if writeError != nil {
+		return writeError
 	}
 	osutils.PrintFileContent(outputfile)
-	osutils.RemoveDirectory(outputfilesDir)
+	if jobLogPath == "" {
+		osutils.RemoveDirectory(outputfilesDir)
+	}
 	return nil
 }
 
@@ -85,6 +289,12 @@ func handleOutputfile() error {
 	if creationFileError != nil {
 		return creationFileError
 	}
+	if jobLogPath != "" {
+		outputfilesDir = jobLogOutputsDirectory()
+	}
+	if isResuming() {
+		return os.MkdirAll(outputfilesDir, 0755)
+	}
 	dirCreationError := osutils.MakeDirectoryDeleteIfExists(outputfilesDir)
 	if dirCreationError != nil {
 		return dirCreationError
@@ -93,15 +303,24 @@ func handleOutputfile() error {
 }
 
 func checkIfbothPlaceholdersMethodsUsed() {
-	// exit if user passed placeholders and filepath
-	if placeholders != "" && filepathInput != "" {
-		logger.Log.Error("You can't use both --placeholder [-p] and --inputfile [-f]")
+	// exit if user passed more than one placeholders method
+	methodsUsed := 0
+	for _, method := range []string{placeholders, filepathInput, inputJSON, inputJSONL, valuesFromOutput} {
+		if method != "" {
+			methodsUsed++
+		}
+	}
+	if methodsUsed > 1 {
+		logger.Log.Error("You can only use one of --placeholder [-p], --inputfile [-f], --input-json, --input-jsonl and --values-from-output")
 		os.Exit(1)
 	}
 }
 
 func validateCommandInput() error {
-	commandPlaceholders := paralixutils.GetMatchedRegexOccurencesFromString("<(.*?)>", command)
+	if optionsError := validateRunOptions(); optionsError != nil {
+		return optionsError
+	}
+	commandPlaceholders := paralixutils.CommandPlaceholders(command)
 	checkIfbothPlaceholdersMethodsUsed()
 	if placeholders != "" {
 		if placeHolderError := validatePlaceholderInput(commandPlaceholders); placeHolderError != nil {
@@ -112,6 +331,55 @@ func validateCommandInput() error {
 		if placeHolderError := validatePlaceholderFileInput(commandPlaceholders); placeHolderError != nil {
 			return placeHolderError
 		}
+	} else if inputJSON != "" || inputJSONL != "" {
+		if len(commandPlaceholders) == 0 {
+			return errors.New("The command should contain at least one <FIELD> placeholder when using JSON input")
+		}
+	} else if valuesFromOutput != "" {
+		if keys := paralixutils.UniqueStrings(commandPlaceholders); len(keys) != 1 {
+			return fmt.Errorf("--values-from-output fills a single placeholder but the command has %d", len(keys))
+		}
+		if _, selectError := jobutils.ParseSelector(selectExpression); selectError != nil {
+			return selectError
+		}
+	}
+	return nil
+}
+
+func validateRunOptions() error {
+	if outputFormat != "text" && outputFormat != "json" && outputFormat != "jsonl" {
+		return fmt.Errorf("--format should be one of text, json, jsonl but got %q", outputFormat)
+	}
+	if jobRetries < 0 {
+		return fmt.Errorf("--retries should be 0 or more but got %d", jobRetries)
+	}
+	if resumeError := validateResumeInput(); resumeError != nil {
+		return resumeError
+	}
+	if startRateInput != "" {
+		var rateError error
+		if startRatePerSecond, rateError = jobutils.ParseRate(startRateInput); rateError != nil {
+			return rateError
+		}
+	}
+	if minFreeMemoryInput != "" {
+		var sizeError error
+		if minFreeMemory, sizeError = paralixutils.ParseByteSize(minFreeMemoryInput); sizeError != nil {
+			return sizeError
+		}
+	}
+	if limitsError := validateJobLimits(); limitsError != nil {
+		return limitsError
+	}
+	if remoteError := prepareRemoteHosts(); remoteError != nil {
+		return remoteError
+	}
+	if executorError := validateExecutorInput(); executorError != nil {
+		return executorError
+	}
+	var haltPolicyError error
+	if haltPolicy, haltPolicyError = jobutils.ParseHaltPolicy(haltPolicyInput); haltPolicyError != nil {
+		return haltPolicyError
 	}
 	return nil
 }
@@ -125,18 +393,21 @@ func validatePlaceholderFileInput(commandPlaceholders []string) error {
 }
 
 func validatePlaceholderInput(commandPlaceholders []string) error {
-	if !paralixutils.CheckAllSubstringsExists([]string{"=", "{", "}"}, placeholders) {
-		return errors.New("Placeholder should be in the format of -p KEY={VALUE1,VALUE2}")
+	groups, err := paralixutils.ParsePlaceholders(placeholders)
+	if err != nil {
+		return err
 	}
-	key := getKeyFromString(placeholders)
-	isExists := paralixutils.IsStringInSlice(commandPlaceholders, key)
-	if !isExists {
-		err := fmt.Sprintf("<%s> is missing in the command", key)
-		return errors.New(err)
+	var placeholdersKeys []string
+	for _, group := range groups {
+		isExists := paralixutils.IsStringInSlice(commandPlaceholders, group.Key)
+		if !isExists {
+			err := fmt.Sprintf("<%s> is missing in the command", group.Key)
+			return errors.New(err)
+		}
+		placeholdersKeys = append(placeholdersKeys, group.Key)
 	}
 
 	// check all command placeholders are passed through -p
-	placeholdersKeys := extractKeys(placeholders)
 	for _, str := range commandPlaceholders {
 		isExists := paralixutils.IsStringInSlice(placeholdersKeys, str)
 		if !isExists {
@@ -147,86 +418,113 @@ func validatePlaceholderInput(commandPlaceholders []string) error {
 	return nil
 }
 
-func getKeyFromString(placeholder string) string {
-	parts := strings.Split(placeholder, "=")
-	if len(parts) != 2 {
-		// The input string is not in the correct format
-		return ""
+func buildJobsFromPlaceholders(commandPlaceholders []string) ([]job, error) {
+	groups, err := paralixutils.ParsePlaceholders(placeholders)
+	if err != nil {
+		return nil, err
 	}
-	return parts[0]
+	// every combination of the groups values is a job, the values of the last group change fastest
+	combinations := []map[string]string{{}}
+	for _, group := range groups {
+		var nextCombinations []map[string]string
+		for _, combination := range combinations {
+			for _, value := range group.Values {
+				values := map[string]string{group.Key: value}
+				for key, previousValue := range combination {
+					values[key] = previousValue
+				}
+				nextCombinations = append(nextCombinations, values)
+			}
+		}
+		combinations = nextCombinations
+	}
+	var jobs []job
+	for i, values := range combinations {
+		jobs = append(jobs, newJob(i+1, values, commandPlaceholders))
+	}
+	return jobs, nil
 }
 
-func extractKeys(s string) []string {
-	var keys []string
-	pairs := strings.Fields(s)
-	for _, pair := range pairs {
-		keyVal := strings.Split(pair, "=")
-		if len(keyVal) != 2 {
-			continue
-		}
-		key := keyVal[0]
-		if !paralixutils.IsStringInSlice(keys, key) {
-			keys = append(keys, key)
-		}
+func buildJobsFromFile() ([]job, error) {
+	key := filepath.Base(filepathInput)
+	values, err := paralixutils.ReadLinesFromFileWithOptions(filepathInput, paralixutils.ReadLinesOptions{
+		SkipBlankLines: skipBlankLines,
+		SkipComments:   skipComments,
+		CommentPrefix:  commentPrefix,
+		TrimWhitespace: trimWhitespace,
+		Dedupe:         dedupeValues,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var jobs []job
+	for i, value := range values {
+		jobs = append(jobs, newJob(i+1, map[string]string{key: value}, []string{key}))
 	}
-	return keys
+	return jobs, nil
 }
 
-func valuesToSlice(str string) ([]string, error) {
-	// find the opening and closing braces
-	if placeholders != "" {
-		values, err := paralixutils.GetValuesBetweenDelimiters(str, "{", "}", ",")
-		if err != nil {
-			return nil, err
-		}
-		return values, nil
+func readJSONInput() ([]map[string]interface{}, error) {
+	if inputJSON != "" {
+		return paralixutils.ReadJSONObjectsFromFile(inputJSON)
 	}
-	if filepathInput != "" {
-		values, err := paralixutils.ReadLinesFromFileReturnSliceOfLines(filepathInput)
-		if err != nil {
-			return nil, err
+	return paralixutils.ReadJSONLinesFromFile(inputJSONL)
+}
+
+func buildJobsFromJSON(commandPlaceholders []string) ([]job, error) {
+	objects, err := readJSONInput()
+	if err != nil {
+		return nil, err
+	}
+	var jobs []job
+	for i, object := range objects {
+		values := make(map[string]string)
+		for _, key := range commandPlaceholders {
+			value, fieldErr := paralixutils.GetNestedFieldAsString(object, key)
+			if fieldErr != nil {
+				return nil, fmt.Errorf("object %d: <%s> can't be filled: %w", i+1, key, fieldErr)
+			}
+			values[key] = value
 		}
-		return values, nil
+		jobs = append(jobs, newJob(i+1, values, commandPlaceholders))
 	}
-	return nil, nil
+	return jobs, nil
 }
 
-func getKeyValuesBasedOnPlaceholderInsertingMethod() (string, []string, error) {
-	var toSend string
-	var key string
-	if placeholders != "" {
-		key = getKeyFromString(placeholders)
-		toSend = placeholders
+func buildJobsFromOutput(key string) ([]job, error) {
+	selector, err := jobutils.ParseSelector(selectExpression)
+	if err != nil {
+		return nil, err
 	}
-	if filepathInput != "" {
-		key = filepathInput
-		toSend = filepathInput
+	records, err := jobutils.ReadResultRecords(valuesFromOutput)
+	if err != nil {
+		return nil, err
 	}
-	values, err := valuesToSlice(toSend)
-	return filepath.Base(key), values, err
+	var jobs []job
+	for _, record := range records {
+		if succeededOnly && !record.Succeeded() {
+			continue
+		}
+		for _, value := range selector.Select(record) {
+			jobs = append(jobs, newJob(len(jobs)+1, map[string]string{key: value}, []string{key}))
+		}
+	}
+	return jobs, nil
 }
 
-func executeParallel() error {
-	key, values, err := getKeyValuesBasedOnPlaceholderInsertingMethod()
-	if err != nil {
-		return err
+func buildJobs() ([]job, error) {
+	commandPlaceholders := paralixutils.UniqueStrings(paralixutils.CommandPlaceholders(command))
+	if inputJSON != "" || inputJSONL != "" {
+		return buildJobsFromJSON(commandPlaceholders)
 	}
-	ch := make(chan string)
-	for _, placeholder := range values {
-		go func(placeholder string) {
-			cmdStr := strings.Replace(command, "<"+key+">", placeholder, -1)
-			cmd := exec.Command("bash", "-c", cmdStr+" | tee "+outputfilesDir+placeholder)
-			// Run command in paralllel report failure to channel if failed [execute/wait]
-			executionErr := paralixutils.RunCmdAndWaitForItToFinish(cmd)
-			if executionErr != nil {
-				ch <- "error"
-			}
-			ch <- "success"
-		}(string(placeholder))
+	if placeholders != "" {
+		return buildJobsFromPlaceholders(commandPlaceholders)
 	}
-	// wait for all the goroutines to complete
-	for range values {
-		<-ch
+	if filepathInput != "" {
+		return buildJobsFromFile()
 	}
-	return nil
+	if valuesFromOutput != "" {
+		return buildJobsFromOutput(commandPlaceholders[0])
+	}
+	return nil, nil
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
+)
+
+func TestJobRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		values   map[string]string
+		want     string
+	}{
+		{name: "every placeholder", template: "cp <SRC> <DST>/<SRC>", values: map[string]string{"SRC": "a.txt", "DST": "/tmp"}, want: "cp a.txt /tmp/a.txt"},
+		{name: "value holding another placeholder", template: "echo <A> <B>", values: map[string]string{"A": "<B>", "B": "<A>"}, want: "echo <B> <A>"},
+		{name: "unknown placeholder", template: "echo <A> <OTHER>", values: map[string]string{"A": "1"}, want: "echo 1 <OTHER>"},
+		{name: "redirect", template: "sort < <FILE> > <FILE>.sorted", values: map[string]string{"FILE": "f"}, want: "sort < f > f.sorted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the map order changes between runs, so a few renders must all agree
+			for i := 0; i < 20; i++ {
+				if got := (job{values: tt.values}).render(tt.template); got != tt.want {
+					t.Fatalf("render() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteResultsToTextFileWithSkippedJobs(t *testing.T) {
+	useFakeExecutor(t, "never", failJobs())
+	savedOutput, savedFormat := outputfile, outputFormat
+	defer func() { outputfile, outputFormat = savedOutput, savedFormat }()
+	outputfile, outputFormat = filepath.Join(t.TempDir(), "out.txt"), "text"
+	jobs := testJobs(2)
+	os.WriteFile(jobs[0].outputFilePath(), []byte("1\n"), 0644)
+	results := []jobutils.Result{{Index: 1}, {Index: 2, SkipReason: upstreamFailedSkipReason}}
+	if err := writeResultstoFile(jobs, results, jobutils.Summary{}); err != nil {
+		t.Fatalf("writeResultstoFile() error = %v", err)
+	}
+	content, _ := os.ReadFile(outputfile)
+	if want := "1\n1\n\n2\nskipped: upstream failed\n\n"; string(content) != want {
+		t.Errorf("output file = %q, want %q", content, want)
+	}
+}
+
+func TestRedirectNextToPlaceholder(t *testing.T) {
+	savedCommand, savedPlaceholders := command, placeholders
+	defer func() { command, placeholders = savedCommand, savedPlaceholders }()
+	command, placeholders = "sort < <FILE>", "FILE={in.txt}"
+	if err := validateCommandInput(); err != nil {
+		t.Fatalf("validateCommandInput() error = %v", err)
+	}
+	jobs, err := buildJobs()
+	if err != nil {
+		t.Fatalf("buildJobs() error = %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].renderCommand() != "sort < in.txt" {
+		t.Errorf("buildJobs() = %+v, want a single sort < in.txt job", jobs)
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tamirdavid/paralix/lib/config"
+)
+
+var profileName string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Profile of the config files to apply over their defaults [Example --profile prod-sweep]")
+}
+
+func loadConfigFiles() (*config.File, *config.File, error) {
+	var userFile, projectFile *config.File
+	userPath, err := config.UserFilePath()
+	if err == nil {
+		if userFile, err = config.LoadFile(userPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	if projectPath := config.FindProjectFile(workingDir); projectPath != "" {
+		if projectFile, err = config.LoadFile(projectPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	return userFile, projectFile, nil
+}
+
+func allFlagNames() map[string]bool {
+	names := make(map[string]bool)
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		cmd.Flags().VisitAll(func(f *pflag.Flag) { names[f.Name] = true })
+		cmd.PersistentFlags().VisitAll(func(f *pflag.Flag) { names[f.Name] = true })
+		for _, child := range cmd.Commands() {
+			visit(child)
+		}
+	}
+	visit(rootCmd)
+	return names
+}
+
+func applyConfig(cmd *cobra.Command) error {
+	// the precedence is flags > PARALIX_* environment variables > project file > user file
+	userFile, projectFile, err := loadConfigFiles()
+	if err != nil {
+		return err
+	}
+	profile := profileName
+	if !cmd.Flags().Changed("profile") {
+		profile = os.Getenv(config.EnvName("profile"))
+	}
+	if profile != "" && !userFile.HasProfile(profile) && !projectFile.HasProfile(profile) {
+		return fmt.Errorf("profile %q is not defined in the config files", profile)
+	}
+	knownFlags := allFlagNames()
+	for _, file := range []*config.File{userFile, projectFile} {
+		for _, key := range file.Keys() {
+			if !knownFlags[key] || key == "profile" {
+				return fmt.Errorf("%s: %q is not a paralix flag", file.Path, key)
+			}
+		}
+	}
+	var flagNames []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		// the hidden flags are only there to be rejected, like the executor flags of paralix serve
+		if f.Name != "profile" && f.Name != "help" && !f.Hidden {
+			flagNames = append(flagNames, f.Name)
+		}
+	})
+	layers := append(userFile.Layers(profile), projectFile.Layers(profile)...)
+	layers = append(layers, config.EnvValues(flagNames, os.Getenv))
+	resolved := config.Resolve(layers...)
+	for _, name := range flagNames {
+		values, ok := resolved[name]
+		if !ok || cmd.Flags().Changed(name) {
+			continue
+		}
+		for _, value := range values {
+			if setError := cmd.Flags().Set(name, value); setError != nil {
+				return fmt.Errorf("invalid value %q for --%s from the config: %v", strings.Join(values, ","), name, setError)
+			}
+		}
+	}
+	return nil
+}
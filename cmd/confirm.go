@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+)
+
+// number of rendered commands shown before asking for confirmation
+const confirmPreviewSize = 5
+
+// openTerminal opens the terminal the confirmation is asked on, tests answer it instead
+var openTerminal = func() (io.ReadWriteCloser, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
+
+func isConfirmationNeeded(jobsCount int) bool {
+	if assumeYes || jobsCount == 0 {
+		return false
+	}
+	return askConfirmation || (confirmThreshold > 0 && jobsCount > confirmThreshold)
+}
+
+func confirmRun(jobs []job) error {
+	if !isConfirmationNeeded(len(jobs)) {
+		return nil
+	}
+	// the prompt goes through the terminal so it works when stdin or stdout are redirected
+	tty, err := openTerminal()
+	if err != nil && !askConfirmation {
+		// the threshold protects interactive runs, scripts, cron jobs and CI run without a terminal as before
+		logger.Log.Debugf("Not asking to confirm %d jobs, there is no terminal", len(jobs))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("--confirm needs a terminal to ask on but there is none, pass --yes to skip it")
+	}
+	defer tty.Close()
+
+	preview := jobs
+	if len(preview) > confirmPreviewSize {
+		preview = preview[:confirmPreviewSize]
+	}
+	fmt.Fprintf(tty, "About to run %d jobs:\n", len(jobs))
+	printJobsAsText(tty, preview)
+	if len(jobs) > len(preview) {
+		fmt.Fprintf(tty, "... and %d more\n", len(jobs)-len(preview))
+	}
+	confirmed, err := osutils.AskForConfirmation(tty, tty, "Run them?")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return errors.New("Run aborted, nothing was executed")
+	}
+	return nil
+}
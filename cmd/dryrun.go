@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type dryRunJob struct {
+	Index   int               `json:"index"`
+	Command string            `json:"command"`
+	Values  map[string]string `json:"values"`
+}
+
+func printDryRun(jobs []job) error {
+	dryRunJobs := make([]dryRunJob, 0, len(jobs))
+	for _, j := range jobs {
+		dryRunJobs = append(dryRunJobs, dryRunJob{Index: j.index, Command: j.renderCommand(), Values: j.values})
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	switch outputFormat {
+	case "json":
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(dryRunJobs)
+	case "jsonl":
+		for _, dryRunJob := range dryRunJobs {
+			if err := encoder.Encode(dryRunJob); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	printJobsAsText(os.Stdout, jobs)
+	fmt.Printf("%d jobs would run\n", len(jobs))
+	return nil
+}
+
+func printJobsAsText(w io.Writer, jobs []job) {
+	for _, j := range jobs {
+		values := make([]string, 0, len(j.keys))
+		for _, key := range j.keys {
+			values = append(values, fmt.Sprintf("%s=%s", key, j.values[key]))
+		}
+		fmt.Fprintf(w, "[%d] %s\n    %s\n", j.index, j.renderCommand(), strings.Join(values, " "))
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"github.com/tamirdavid/paralix/lib/executor"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+var executorName string
+var containerImage string
+var containerRuntime string
+
+func addExecutorFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&executorName, "executor", "", fmt.Sprintf("Executor that runs the jobs %v, by default container with --container, ssh with remote hosts and local otherwise", executor.Names()))
+	flags.StringVar(&containerImage, "container", "", "Run every job in a new container of this image with the working directory mounted [Example --container alpine:3.19]")
+	flags.StringVar(&containerRuntime, "container-runtime", "", "Container runtime used by --container, by default docker or podman, whichever is found on the PATH")
+}
+
+func selectedExecutor() string {
+	if executorName != "" {
+		return executorName
+	}
+	if containerImage != "" {
+		return "container"
+	}
+	if isRemote() {
+		return "ssh"
+	}
+	return "local"
+}
+
+func validateExecutorInput() error {
+	name := selectedExecutor()
+	if !paralixutils.IsStringInSlice(executor.Names(), name) {
+		return fmt.Errorf("unknown executor %q, available executors are %v", name, executor.Names())
+	}
+	if name == "ssh" && !isRemote() {
+		return fmt.Errorf("the ssh executor needs hosts from --ssh-hosts or --ssh-login [-S]")
+	}
+	if name == "coordinator" && !serving {
+		return fmt.Errorf("the coordinator executor queues the jobs for the workers of paralix serve, use paralix serve instead")
+	}
+	if name == "coordinator" && (isRemote() || containerImage != "") {
+		return fmt.Errorf("the jobs of paralix serve run on its workers, --container and remote hosts can't be used with it")
+	}
+	if containerImage != "" && isRemote() {
+		return fmt.Errorf("--container can't be used with remote hosts")
+	}
+	if name == "container" && containerImage == "" {
+		return fmt.Errorf("the container executor needs an image from --container")
+	}
+	if name == "container" {
+		if _, runtimeError := executor.FindContainerRuntime(containerRuntime); runtimeError != nil {
+			return runtimeError
+		}
+	}
+	return nil
+}
+
+func newExecutor() (executor.Executor, error) {
+	return executor.New(selectedExecutor(), executor.Options{
+		Limits:           jobLimits,
+		CgroupParent:     cgroupParent,
+		SSHProgram:       sshProgram,
+		ContainerRuntime: containerRuntime,
+		ContainerImage:   containerImage,
+		ListenAddress:    listenAddress,
+		LeaseDuration:    leaseDuration,
+		Token:            coordinatorToken,
+	})
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+const (
+	procSelfCgroupPath = "/proc/self/cgroup"
+	cgroupRootPath     = "/sys/fs/cgroup"
+)
+
+func validateJobLimits() error {
+	if jobMemoryInput != "" {
+		var sizeError error
+		if jobLimits.Memory, sizeError = paralixutils.ParseByteSize(jobMemoryInput); sizeError != nil {
+			return sizeError
+		}
+	}
+	// the container runtime enforces the limits of the containers itself
+	if containerImage != "" || cgroupParent == "" {
+		return nil
+	}
+	if controllers := jobLimits.CgroupControllers(); len(controllers) > 0 {
+		return osutils.CheckCgroupControllers(cgroupParent, controllers)
+	}
+	return nil
+}
+
+// setupJobCgroups finds the cgroup the jobs run in once they are about to run, the returned function
+// restores the cgroup of paralix after the jobs finished
+func setupJobCgroups() (func(), error) {
+	controllers := jobLimits.CgroupControllers()
+	if containerImage != "" || cgroupParent != "" || len(controllers) == 0 || selectedExecutor() != "local" {
+		return func() {}, nil
+	}
+	// without an explicit parent, the job cgroups are created under the cgroup paralix runs in
+	parent, err := osutils.FindCgroupParent(procSelfCgroupPath, cgroupRootPath, controllers)
+	if err != nil {
+		if jobLimits.CPU > 0 {
+			return nil, fmt.Errorf("--job-cpu needs a cgroup v2 for the jobs (%v), pass a delegated cgroup with --cgroup-parent or run paralix in a cgroup of its own, like with systemd-run --user --scope -p Delegate=yes", err)
+		}
+		logger.Log.Warnf("Can't use cgroups for the job limits, using rlimits instead (pass a delegated cgroup with --cgroup-parent to use them): %v", err)
+		return func() {}, nil
+	}
+	cgroupParent = parent.Dir
+	return func() {
+		cgroupParent = ""
+		if err := parent.Restore(); err != nil {
+			logger.Log.Warnf("Failed to restore the cgroup of paralix: %v", err)
+		}
+	}, nil
+}
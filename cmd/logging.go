@@ -0,0 +1,17 @@
+package cmd
+
+import "github.com/tamirdavid/paralix/lib/logger"
+
+var logOptions logger.Options
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&logOptions.Level, "log-level", "info", "Minimal level of the logged messages: error, warn, info, debug or trace")
+	rootCmd.PersistentFlags().StringVar(&logOptions.Format, "log-format", "text", "Format of the log lines: text, or json with a field for every job detail")
+	rootCmd.PersistentFlags().StringVar(&logOptions.File, "log-file", "", "Append the logs to this file instead of writing them on stderr")
+}
+
+func configureLogging() error {
+	// the log file stays open until paralix exits
+	_, err := logger.Configure(logger.Log, logOptions)
+	return err
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
+	"github.com/tamirdavid/paralix/lib/logger"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+)
+
+func startProgress(total int) *jobutils.Progress {
+	// progress is reported on stderr only, stdout keeps the commands output
+	isTerminal := osutils.IsTerminal(os.Stderr)
+	progress := jobutils.NewProgress(os.Stderr, total, isTerminal)
+	// the logs written on stderr keep the progress line below them, a --log-file is left alone
+	if isTerminal && logger.Log.Out == os.Stderr {
+		logger.Log.SetOutput(progress.LogWriter(os.Stderr))
+	}
+	progress.Start()
+	return progress
+}
+
+func stopProgress(progress *jobutils.Progress) {
+	progress.Stop()
+	// only the progress log writer is replaced, stderr and a --log-file are files
+	if _, isFile := logger.Log.Out.(*os.File); !isFile {
+		logger.Log.SetOutput(os.Stderr)
+	}
+}
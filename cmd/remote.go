@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/tamirdavid/paralix/lib/executor"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+	sshutils "github.com/tamirdavid/paralix/lib/sshUtils"
+)
+
+var sshHostsFile string
+var sshLogins string
+var sshProgram string
+var remoteHosts []sshutils.Host
+var transferKeys []string
+var returnPaths []string
+
+// jobSlot is a place for a job to run, on the local machine when host is empty
+type jobSlot struct {
+	number int
+	host   string
+}
+
+func addRemoteFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&sshHostsFile, "ssh-hosts", "", "File with the hosts to run the jobs on over SSH, one [N/][user@]host per line where N is the number of jobs the host runs at once")
+	flags.StringVarP(&sshLogins, "ssh-login", "S", "", "Comma separated hosts to run the jobs on over SSH [Example -S 'user@h1,4/h2']")
+	flags.StringVar(&sshProgram, "ssh", "ssh", "SSH client used to reach the --ssh-hosts [-S]")
+	flags.StringArrayVar(&transferKeys, "transfer-file", nil, "Copy the local file named by the value of this placeholder to the remote host before running the job [Example --transfer-file INPUT]")
+	flags.StringArrayVar(&returnPaths, "return", nil, "Fetch this path, relative to the remote job directory and with placeholders, back into <output>.return/<job index>/ after the job [Example --return '<NAME>.out']")
+}
+
+func isRemote() bool {
+	return len(remoteHosts) > 0
+}
+
+func prepareRemoteHosts() error {
+	if sshHostsFile != "" && sshLogins != "" {
+		return errors.New("You can only use one of --ssh-hosts and --ssh-login [-S]")
+	}
+	if sshHostsFile == "" && sshLogins == "" {
+		if len(transferKeys) > 0 || len(returnPaths) > 0 {
+			return errors.New("--transfer-file and --return need remote hosts from --ssh-hosts or --ssh-login [-S]")
+		}
+		return nil
+	}
+	commandPlaceholders := paralixutils.CommandPlaceholders(command)
+	for _, key := range transferKeys {
+		if !paralixutils.IsStringInSlice(commandPlaceholders, key) {
+			return fmt.Errorf("--transfer-file %s: <%s> is missing in the command", key, key)
+		}
+	}
+	if !jobLimits.IsEmpty() || jobMemoryInput != "" {
+		return errors.New("--job-memory, --job-cpu and --job-nofile can't be used with remote hosts")
+	}
+	// -j is the number of jobs of each host that doesn't set its own
+	defaultSlots := parallelJobs
+	if defaultSlots <= 0 {
+		defaultSlots = 1
+	}
+	var err error
+	if sshHostsFile != "" {
+		remoteHosts, err = sshutils.ReadHostsFile(sshHostsFile, defaultSlots)
+	} else {
+		remoteHosts, err = sshutils.ParseHostsList(sshLogins, defaultSlots)
+	}
+	return err
+}
+
+func newJobSlots(jobsCount int) []jobSlot {
+	var slots []jobSlot
+	if !isRemote() {
+		count := parallelJobs
+		if count <= 0 || count > jobsCount {
+			count = jobsCount
+		}
+		for number := 1; number <= count; number++ {
+			slots = append(slots, jobSlot{number: number})
+		}
+		return slots
+	}
+	// the slots of the hosts are interleaved so the first jobs are spread over all the hosts
+	for round := 0; len(slots) < jobsCount; round++ {
+		added := false
+		for _, host := range remoteHosts {
+			if round < host.Slots && len(slots) < jobsCount {
+				slots = append(slots, jobSlot{number: len(slots) + 1, host: host.Destination})
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return slots
+}
+
+func remoteTransferPath(localPath string) string {
+	// relative paths keep their layout in the remote job directory, other files are copied by their name
+	cleaned := filepath.ToSlash(filepath.Clean(localPath))
+	if filepath.IsAbs(localPath) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return path.Base(cleaned)
+	}
+	return cleaned
+}
+
+func (j job) withTransfers() (job, []executor.Transfer) {
+	// the transferred values are replaced by the remote paths, so the command finds the copies
+	if len(transferKeys) == 0 {
+		return j, nil
+	}
+	values := make(map[string]string, len(j.values))
+	for key, value := range j.values {
+		values[key] = value
+	}
+	var transfers []executor.Transfer
+	for _, key := range transferKeys {
+		remote := remoteTransferPath(j.values[key])
+		transfers = append(transfers, executor.Transfer{Local: j.values[key], Remote: remote})
+		values[key] = remote
+	}
+	j.values = values
+	return j, transfers
+}
+
+func (j job) returnedFilesDirectory() string {
+	return filepath.Join(outputfile+".return", fmt.Sprintf("%06d", j.index))
+}
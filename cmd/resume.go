@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
+	"github.com/tamirdavid/paralix/lib/logger"
+)
+
+func isResuming() bool {
+	return resume || resumeFailed
+}
+
+func validateResumeInput() error {
+	if resume && resumeFailed {
+		return errors.New("You can't use both --resume and --resume-failed")
+	}
+	if isResuming() && jobLogPath == "" {
+		return errors.New("--resume and --resume-failed need the --joblog of the previous run")
+	}
+	return nil
+}
+
+func jobLogOutputsDirectory() string {
+	// the jobs output is kept next to the job log so a resumed run can merge it into the output file
+	return jobLogPath + ".output" + string(filepath.Separator)
+}
+
+// detailsFilePath is where the job log details of the job are kept, next to its output
+func (j job) detailsFilePath() string {
+	return j.outputFilePath() + ".json"
+}
+
+func splitResumedJobs(jobs []job) ([]job, map[int]jobutils.Result, error) {
+	if !isResuming() {
+		return jobs, nil, nil
+	}
+	entries, err := jobutils.ReadJobLog(jobLogPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return jobs, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// a job that ran more than once is judged by its latest run
+	latestEntries := make(map[string]jobutils.JobLogEntry)
+	for _, entry := range entries {
+		latestEntries[entry.Command] = entry
+	}
+
+	var jobsToRun []job
+	previousResults := make(map[int]jobutils.Result)
+	previousOutputs := make(map[int][]byte)
+	previousDetails := make(map[int]*jobutils.JobLogDetails)
+	for position, j := range jobs {
+		entry, found := latestEntries[j.renderCommand()]
+		if !found || (resumeFailed && !entry.Succeeded()) {
+			jobsToRun = append(jobsToRun, j)
+			continue
+		}
+		previousJob := job{index: entry.Seq}
+		output, err := os.ReadFile(previousJob.outputFilePath())
+		if err != nil {
+			// without the previous output the job has to run again for the output file to be complete
+			jobsToRun = append(jobsToRun, j)
+			continue
+		}
+		previousOutputs[position] = output
+		// the job logs of older runs have no details, their jobs are reported from the job log line alone
+		if details, err := jobutils.ReadJobLogDetails(previousJob.detailsFilePath()); err == nil {
+			previousDetails[position] = &details
+		}
+		previousResults[position] = resultFromJobLogEntry(j, entry, previousDetails[position])
+	}
+	// the outputs are written only after all of them are read, since the jobs order may have changed
+	for position, output := range previousOutputs {
+		if err := os.WriteFile(jobs[position].outputFilePath(), output, 0644); err != nil {
+			return nil, nil, err
+		}
+		detailsErr := os.Remove(jobs[position].detailsFilePath())
+		if details := previousDetails[position]; details != nil {
+			detailsErr = jobutils.WriteJobLogDetails(jobs[position].detailsFilePath(), *details)
+		}
+		if detailsErr != nil && !errors.Is(detailsErr, fs.ErrNotExist) {
+			return nil, nil, detailsErr
+		}
+	}
+	logger.Log.Infof("Resuming from %s: %d jobs are already done, %d jobs are left to run", jobLogPath, len(previousResults), len(jobsToRun))
+	return jobsToRun, previousResults, nil
+}
+
+func resultFromJobLogEntry(j job, entry jobutils.JobLogEntry, details *jobutils.JobLogDetails) jobutils.Result {
+	result := jobutils.Result{
+		Index:    j.index,
+		Label:    j.label,
+		Values:   j.values,
+		Command:  entry.Command,
+		Slot:     entry.Slot,
+		Start:    entry.Start,
+		Duration: entry.Runtime,
+		ExitCode: entry.ExitCode,
+		Signal:   entry.Signal,
+	}
+	if entry.Host != jobutils.LocalHost {
+		result.Host = entry.Host
+	}
+	if details != nil {
+		result.Attempts = details.Attempts
+		result.TimedOut = details.TimedOut
+		result.LimitExceeded = details.LimitExceeded
+		if details.Error != "" {
+			result.Err = errors.New(details.Error)
+		}
+		return result
+	}
+	if entry.Signal != 0 {
+		result.Err = fmt.Errorf("killed by signal %d", entry.Signal)
+	} else if entry.ExitCode != 0 {
+		result.Err = fmt.Errorf("exit status %d", entry.ExitCode)
+	}
+	return result
+}
+
+func mergeResumedResults(jobs []job, executedResults []jobutils.Result, previousResults map[int]jobutils.Result) []jobutils.Result {
+	if previousResults == nil {
+		return executedResults
+	}
+	// the executed results are in the order of the jobs that were left to run
+	results := make([]jobutils.Result, 0, len(jobs))
+	next := 0
+	for position := range jobs {
+		if previous, found := previousResults[position]; found {
+			results = append(results, previous)
+			continue
+		}
+		results = append(results, executedResults[next])
+		next++
+	}
+	return results
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tamirdavid/paralix/lib/executor"
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
+)
+
+// useJobLog keeps the job log of a test and the outputs next to it in a temporary directory
+func useJobLog(t *testing.T) {
+	t.Helper()
+	savedJobLog, savedResume, savedResumeFailed := jobLogPath, resume, resumeFailed
+	t.Cleanup(func() {
+		jobLogPath, resume, resumeFailed = savedJobLog, savedResume, savedResumeFailed
+	})
+	jobLogPath = filepath.Join(t.TempDir(), "run.log")
+	resume, resumeFailed = false, false
+	outputfilesDir = jobLogOutputsDirectory()
+	if err := os.MkdirAll(outputfilesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResumeKeepsTheDetailsOfTheJobs(t *testing.T) {
+	useFakeExecutor(t, "never", func(job executor.Job) executor.Outcome {
+		switch job.Index {
+		case 2:
+			return executor.Outcome{ExitCode: -1, TimedOut: true, Err: errors.New("timed out after 1s")}
+		case 3:
+			return executor.Outcome{ExitCode: -1, Signal: 9, LimitExceeded: "memory", Err: errors.New("memory limit exceeded")}
+		}
+		job.Stdout.Write([]byte("done\n"))
+		return executor.Outcome{}
+	})
+	useJobLog(t)
+	jobs := testJobs(3)
+	executed, err := executeParallel(jobs)
+	if err != nil {
+		t.Fatalf("executeParallel() error = %v", err)
+	}
+
+	resume = true
+	// the jobs order changed since the run, the details must follow the jobs
+	reordered := []job{jobs[2], jobs[0], jobs[1]}
+	for position := range reordered {
+		reordered[position].index = position + 1
+	}
+	jobsToRun, previous, err := splitResumedJobs(reordered)
+	if err != nil {
+		t.Fatalf("splitResumedJobs() error = %v", err)
+	}
+	if len(jobsToRun) != 0 {
+		t.Fatalf("%d jobs are left to run, want none", len(jobsToRun))
+	}
+	resumed := mergeResumedResults(reordered, nil, previous)
+	for position, result := range resumed {
+		want := executed[[]int{2, 0, 1}[position]]
+		if result.Attempts != want.Attempts || result.TimedOut != want.TimedOut || result.LimitExceeded != want.LimitExceeded {
+			t.Errorf("resumed job %q = %+v, want the details of %+v", result.Command, result, want)
+		}
+		if (result.Err == nil) != (want.Err == nil) || (result.Err != nil && result.Err.Error() != want.Err.Error()) {
+			t.Errorf("resumed job %q error = %v, want %v", result.Command, result.Err, want.Err)
+		}
+	}
+	if stdout, err := os.ReadFile(reordered[1].outputFilePath()); err != nil || string(stdout) != "done\n" {
+		t.Errorf("output of the resumed job = %q, %v, want %q", stdout, err, "done\n")
+	}
+}
+
+func TestWriteStructuredResultsIgnoresTheOutputsOfSkippedJobs(t *testing.T) {
+	savedOutputfile, savedFormat, savedOutputs, savedJobLog := outputfile, outputFormat, outputfilesDir, jobLogPath
+	t.Cleanup(func() {
+		outputfile, outputFormat, outputfilesDir, jobLogPath = savedOutputfile, savedFormat, savedOutputs, savedJobLog
+	})
+	dir := t.TempDir()
+	outputfile = filepath.Join(dir, "out.jsonl")
+	outputFormat = "jsonl"
+	jobLogPath = filepath.Join(dir, "run.log")
+	outputfilesDir = jobLogOutputsDirectory()
+	jobs := testJobs(2)
+	if err := os.MkdirAll(outputfilesDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// the outputs of a previous run with other jobs are still in the directory of the job log
+	for _, j := range jobs {
+		if err := os.WriteFile(j.outputFilePath(), []byte("stale\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(jobs[0].outputFilePath(), []byte("fresh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := []jobutils.Result{
+		{Index: 1, Command: "job 1", Attempts: 1},
+		{Index: 2, Command: "job 2", SkipReason: upstreamFailedSkipReason},
+	}
+	if err := writeStructuredResultsToFile(jobs, results, jobutils.Summarize(results, 0, 0)); err != nil {
+		t.Fatalf("writeStructuredResultsToFile() error = %v", err)
+	}
+	content, err := os.ReadFile(outputfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var stdouts []string
+	for range results {
+		var record jobutils.ResultRecord
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		stdouts = append(stdouts, record.Stdout)
+	}
+	if want := []string{"fresh\n", ""}; !reflect.DeepEqual(stdouts, want) {
+		t.Errorf("stdouts = %q, want %q", stdouts, want)
+	}
+}
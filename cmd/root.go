@@ -4,6 +4,7 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -18,13 +19,38 @@ executing commands in parallel can significantly reduce the amount
 of time required to complete the tasks. `,
 }
 
+// exitCodeError makes paralix exit with a specific code instead of 1
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// prepareRun applies the config files to the flags of the executing command and then configures the logging,
+// so the config files can set the logging flags too. It runs before the required flags are checked,
+// so the config files can also fill the required flags
+func prepareRun(cmd *cobra.Command, args []string) error {
+	if err := applyConfig(cmd); err != nil {
+		return err
+	}
+	return configureLogging()
+}
+
 func init() {
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentPreRunE = prepareRun
 }
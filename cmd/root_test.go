@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
+)
+
+// useWorkDir runs a test in a new working directory with no config files and returns it
+func useWorkDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	savedDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	savedOutputs := outputfilesDir
+	t.Cleanup(func() {
+		os.Chdir(savedDir)
+		outputfilesDir = savedOutputs
+		resetFlags(rootCmd)
+	})
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	return dir
+}
+
+// resetFlags sets every flag of cmd and of its subcommands back to its default, like a new paralix process
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
+
+// runParalix runs paralix with args in the working directory of useWorkDir
+func runParalix(t *testing.T, args ...string) error {
+	t.Helper()
+	resetFlags(rootCmd)
+	outputfilesDir = filepath.Join(t.TempDir(), "outputs")
+	var usage bytes.Buffer
+	rootCmd.SetOut(&usage)
+	rootCmd.SetErr(&usage)
+	rootCmd.SetArgs(args)
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+	}()
+	return rootCmd.Execute()
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readResultRecords(t *testing.T, path string) []jobutils.ResultRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []jobutils.ResultRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// the last line is the summary
+		if strings.HasPrefix(scanner.Text(), `{"summary"`) {
+			continue
+		}
+		var record jobutils.ResultRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestDryRunDoesNotTouchTheOutputs(t *testing.T) {
+	dir := useWorkDir(t)
+	writeTestFile(t, filepath.Join(dir, "out"), "previous output\n")
+	writeTestFile(t, filepath.Join(dir, "run.log"), "previous job log\n")
+	if err := runParalix(t, "command", "-e", "echo <N> > ran.<N>", "-p", "N={1,2}", "-o", "out", "--joblog", "run.log", "--dry-run"); err != nil {
+		t.Fatalf("dry run error = %v", err)
+	}
+	for name, want := range map[string]string{"out": "previous output\n", "run.log": "previous job log\n"} {
+		if content, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(content) != want {
+			t.Errorf("%s = %q, %v after a dry run, want %q", name, content, err, want)
+		}
+	}
+	for _, name := range []string{"run.log.output", "ran.1", "ran.2"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s exists after a dry run", name)
+		}
+	}
+}
+
+// fakeTerminal answers the confirmation prompt
+type fakeTerminal struct {
+	io.Reader
+	io.Writer
+}
+
+func (fakeTerminal) Close() error {
+	return nil
+}
+
+func TestConfirmation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		// the answer typed in the terminal, no terminal at all when empty
+		answer  string
+		wantRun bool
+		wantErr bool
+	}{
+		{name: "confirmed", args: []string{"--confirm"}, answer: "y\n", wantRun: true},
+		{name: "declined", args: []string{"--confirm"}, answer: "n\n", wantErr: true},
+		{name: "no terminal to ask on", args: []string{"--confirm"}, wantErr: true},
+		{name: "yes skips the prompt", args: []string{"--confirm", "--yes"}, wantRun: true},
+		{name: "over the threshold", args: []string{"--confirm-threshold", "1"}, answer: "n\n", wantErr: true},
+		{name: "over the threshold without a terminal", args: []string{"--confirm-threshold", "1"}, wantRun: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useWorkDir(t)
+			savedOpenTerminal := openTerminal
+			t.Cleanup(func() { openTerminal = savedOpenTerminal })
+			asked := false
+			openTerminal = func() (io.ReadWriteCloser, error) {
+				asked = true
+				if tt.answer == "" {
+					return nil, errors.New("no terminal")
+				}
+				return fakeTerminal{Reader: strings.NewReader(tt.answer), Writer: io.Discard}, nil
+			}
+			args := append([]string{"command", "-e", "echo <N> > ran.<N>", "-p", "N={1,2}", "-o", "out"}, tt.args...)
+			err := runParalix(t, args...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run error = %v, want error %v", err, tt.wantErr)
+			}
+			if assumeYes && asked {
+				t.Errorf("--yes asked for confirmation")
+			}
+			for _, name := range []string{"out", "ran.1", "ran.2"} {
+				if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != tt.wantRun {
+					t.Errorf("%s exists = %v, want %v", name, err == nil, tt.wantRun)
+				}
+			}
+		})
+	}
+}
+
+func TestResumeMergesThePreviousRun(t *testing.T) {
+	dir := useWorkDir(t)
+	// job 2 fails until the fixed file exists
+	args := []string{"command", "-e", "echo <N>; echo <N> >> ran; test <N> != 2 -o -e fixed", "-p", "N={1..3}", "-o", "out.jsonl", "--format", "jsonl", "--joblog", "run.log"}
+	if err := runParalix(t, args...); err != nil {
+		t.Fatalf("first run error = %v", err)
+	}
+	writeTestFile(t, filepath.Join(dir, "fixed"), "")
+	if err := runParalix(t, append(args, "--resume-failed")...); err != nil {
+		t.Fatalf("resumed run error = %v", err)
+	}
+	ran, err := os.ReadFile(filepath.Join(dir, "ran"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := strings.Fields(string(ran))
+	sort.Strings(runs)
+	if want := []string{"1", "2", "2", "3"}; !reflect.DeepEqual(runs, want) {
+		t.Errorf("jobs ran %v, want only the failed job 2 to run again", runs)
+	}
+	records := readResultRecords(t, filepath.Join(dir, "out.jsonl"))
+	if len(records) != 3 {
+		t.Fatalf("the output file has %d results, want 3", len(records))
+	}
+	for position, record := range records {
+		want := strconv.Itoa(position + 1)
+		if record.Index != position+1 || record.ExitCode != 0 || record.Stdout != want+"\n" || record.Attempts != 1 {
+			t.Errorf("result %d = %+v, want the successful job %s", position, record, want)
+		}
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name        string
+		userFile    string
+		projectFile string
+		env         string
+		args        []string
+		want        int
+	}{
+		{name: "default", want: 5},
+		{name: "user file", userFile: "defaults:\n  slowest: 1\n", want: 1},
+		{name: "project file over user file", userFile: "defaults:\n  slowest: 1\n", projectFile: "defaults:\n  slowest: 2\n", want: 2},
+		{name: "environment over files", userFile: "defaults:\n  slowest: 1\n", projectFile: "defaults:\n  slowest: 2\n", env: "3", want: 3},
+		{name: "flag over everything", projectFile: "defaults:\n  slowest: 2\n", env: "3", args: []string{"--slowest", "4"}, want: 4},
+		{name: "profile over defaults", projectFile: "defaults:\n  slowest: 2\nprofiles:\n  ci:\n    slowest: 6\n", args: []string{"--profile", "ci"}, want: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useWorkDir(t)
+			if tt.userFile != "" {
+				writeTestFile(t, filepath.Join(dir, "config", "paralix", "config.yaml"), tt.userFile)
+			}
+			if tt.projectFile != "" {
+				writeTestFile(t, filepath.Join(dir, ".paralix.yaml"), tt.projectFile)
+			}
+			t.Setenv("PARALIX_SLOWEST", tt.env)
+			args := append([]string{"command", "-e", "echo <N>", "-p", "N={1}", "-o", "out", "--dry-run"}, tt.args...)
+			if err := runParalix(t, args...); err != nil {
+				t.Fatalf("run error = %v", err)
+			}
+			if slowestCount != tt.want {
+				t.Errorf("--slowest = %d, want %d", slowestCount, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFillsRequiredFlags(t *testing.T) {
+	dir := useWorkDir(t)
+	writeTestFile(t, filepath.Join(dir, ".paralix.yaml"), "defaults:\n  output: from-config\n")
+	if err := runParalix(t, "command", "-e", "echo <N>", "-p", "N={1}", "--yes"); err != nil {
+		t.Fatalf("run error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "from-config")); err != nil {
+		t.Errorf("the output file of the config was not written: %v", err)
+	}
+}
+
+func TestServeRejectsUnsupportedFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		projectFile string
+		wantErr     string
+	}{
+		{name: "container", args: []string{"--container", "alpine"}, wantErr: "--container can't be used with paralix serve"},
+		{name: "executor", args: []string{"--executor", "local"}, wantErr: "--executor can't be used with paralix serve"},
+		{name: "remote hosts", args: []string{"-S", "h1"}, wantErr: "--ssh-login can't be used with paralix serve"},
+		{name: "job limits", args: []string{"--job-memory", "1G"}, wantErr: "--job-memory can't be used with paralix serve"},
+		{name: "short lease", args: []string{"--lease", "10ms"}, wantErr: "--lease must be at least"},
+		// the config files apply the flags of paralix command, not the hidden ones of paralix serve
+		{name: "container from the config", projectFile: "defaults:\n  container: alpine\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useWorkDir(t)
+			if tt.projectFile != "" {
+				writeTestFile(t, filepath.Join(dir, ".paralix.yaml"), tt.projectFile)
+			}
+			args := append([]string{"serve", "-e", "echo <N>", "-p", "N={1}", "-o", "out", "--dry-run"}, tt.args...)
+			err := runParalix(t, args...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("run error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("run error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCoordinatorExecutorIsForServeOnly(t *testing.T) {
+	useWorkDir(t)
+	err := runParalix(t, "command", "-e", "echo <N>", "-p", "N={1}", "-o", "out", "--executor", "coordinator", "--dry-run")
+	if err == nil || !strings.Contains(err.Error(), "use paralix serve instead") {
+		t.Errorf("run error = %v, want the coordinator executor rejected", err)
+	}
+}
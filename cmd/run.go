@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+	"github.com/tamirdavid/paralix/lib/spec"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run SPEC_FILE",
+	Short: "Run the jobs declared in a spec file.",
+	Long: `Run the jobs declared in a YAML spec file: the command template, the sources of the placeholder values,
+the concurrency, retries, timeout and outputs. The spec is validated before anything runs, and the jobs run
+exactly like the jobs of paralix command. A spec with stages runs a pipeline, where every job starts as soon as
+the upstream jobs it uses the results of succeeded, and a spec with tasks runs every task once the tasks it needs succeeded.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobSpec, loadError := spec.Load(args[0])
+		if loadError != nil {
+			return loadError
+		}
+		applySpec(jobSpec)
+		if optionsError := validateRunOptions(); optionsError != nil {
+			return optionsError
+		}
+		jobs, buildJobsError := buildJobsFromSpec(jobSpec)
+		if buildJobsError != nil {
+			return buildJobsError
+		}
+		return runJobs(cmd, jobs)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Validate the spec and print the jobs that would run without executing them")
+	runCmd.Flags().BoolVar(&askConfirmation, "confirm", false, "Show the jobs that are about to run and ask for confirmation before running them")
+	runCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Run without asking for confirmation (for CI)")
+	runCmd.Flags().BoolVar(&showProgress, "progress", false, "Show the jobs progress and ETA on stderr")
+	runCmd.Flags().BoolVar(&showSummary, "summary", true, "Print a summary of the run with timing statistics on stderr")
+}
+
+func applySpec(jobSpec *spec.Spec) {
+	command = jobSpec.Command
+	outputfile = jobSpec.Output.Path
+	outputFormat = jobSpec.Output.Format
+	parallelJobs = jobSpec.Concurrency
+	jobRetries = jobSpec.Retries
+	jobTimeout = jobSpec.Timeout
+	jobLogPath = jobSpec.JobLog
+}
+
+func buildJobsFromSpec(jobSpec *spec.Spec) ([]job, error) {
+	if jobSpec.IsPipeline() {
+		return buildJobsFromPipeline(jobSpec)
+	}
+	if jobSpec.IsDAG() {
+		return buildJobsFromTasks(jobSpec), nil
+	}
+	combinations, err := jobSpec.Combinations()
+	if err != nil {
+		return nil, err
+	}
+	keys := paralixutils.UniqueStrings(paralixutils.CommandPlaceholders(command))
+	jobs := make([]job, 0, len(combinations))
+	for i, values := range combinations {
+		jobs = append(jobs, newJob(i+1, values, keys))
+	}
+	return jobs, nil
+}
+
+func buildJobsFromTasks(jobSpec *spec.Spec) []job {
+	taskNeeds := jobSpec.TaskNeeds()
+	jobs := make([]job, 0, len(jobSpec.Tasks))
+	for i, task := range jobSpec.Tasks {
+		j := newJob(i+1, map[string]string{}, nil)
+		j.template = task.Command
+		j.label = task.Name
+		for _, position := range taskNeeds[i] {
+			j.needs = append(j.needs, position+1)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+func buildJobsFromPipeline(jobSpec *spec.Spec) ([]job, error) {
+	pipelineJobs, err := jobSpec.PipelineJobs()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]job, 0, len(pipelineJobs))
+	for i, pipelineJob := range pipelineJobs {
+		j := newJob(i+1, pipelineJob.Values, pipelineJob.Keys)
+		j.template = pipelineJob.Command
+		// the stage tells apart the jobs of different stages with the same values
+		j.label = strings.TrimSpace(pipelineJob.Stage + " " + j.label)
+		for _, position := range pipelineJob.Needs {
+			j.needs = append(j.needs, position+1)
+		}
+		if len(jobSpec.References(pipelineJob.Command)) > 0 {
+			pipelineJob := pipelineJob
+			j.prepare = func(j job) (job, error) {
+				// the upstream values are known only once the upstream jobs finished
+				upstreamValues, err := jobSpec.UpstreamValues(pipelineJobs, pipelineJob, func(position int) (string, error) {
+					stdout, err := os.ReadFile(job{index: position + 1}.outputFilePath())
+					return string(stdout), err
+				})
+				if err != nil {
+					return j, err
+				}
+				values := make(map[string]string, len(j.values)+len(upstreamValues))
+				for key, value := range j.values {
+					values[key] = value
+				}
+				for key, value := range upstreamValues {
+					values[key] = value
+				}
+				j.values = values
+				return j, nil
+			}
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tamirdavid/paralix/lib/executor"
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
+	"github.com/tamirdavid/paralix/lib/logger"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+const (
+	haltedSkipReason         = "halted"
+	upstreamFailedSkipReason = "upstream failed"
+)
+
+type haltError struct {
+	trigger jobutils.Result
+}
+
+func (e *haltError) Error() string {
+	if e.trigger.Succeeded() {
+		return fmt.Sprintf("halted after job %d succeeded", e.trigger.Index)
+	}
+	return fmt.Sprintf("halted after job %d failed: %v", e.trigger.Index, e.trigger.Err)
+}
+
+func (e *haltError) exitError() error {
+	if e.trigger.Succeeded() {
+		logger.Log.Info(e.Error())
+		return nil
+	}
+	// the exit code of paralix is the exit code of the job that triggered the halt
+	code := e.trigger.ExitCode
+	if code <= 0 {
+		code = 1
+	}
+	return &exitCodeError{code: code, err: e}
+}
+
+func runJob(jobExecutor executor.Executor, j job, slot jobSlot, cancel <-chan struct{}) jobutils.Result {
+	if j.prepare != nil {
+		prepared, err := j.prepare(j)
+		if err != nil {
+			return jobutils.Result{Index: j.index, Label: j.label, Values: j.values, Command: j.renderCommand(), Host: slot.host, Slot: slot.number, Start: time.Now(), ExitCode: -1, Err: err}
+		}
+		j = prepared
+	}
+	result := jobutils.Result{Index: j.index, Label: j.label, Values: j.values, Command: j.renderCommand(), Host: slot.host, Slot: slot.number, Start: time.Now()}
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+		runJobAttempt(jobExecutor, j, slot, cancel, &result)
+		// a killed job is not retried, it was killed on purpose
+		if result.Succeeded() || attempt > jobRetries || errors.Is(result.Err, paralixutils.ErrCanceled) {
+			break
+		}
+		logger.Log.Warnf("Job %d failed on attempt %d of %d, retrying: %v", result.Index, attempt, jobRetries+1, result.Err)
+	}
+	result.Duration = time.Since(result.Start)
+	return result
+}
+
+func runJobAttempt(jobExecutor executor.Executor, j job, slot jobSlot, cancel <-chan struct{}, result *jobutils.Result) {
+	// the output of a retried job is the output of its last attempt. The stdout goes to the output file only,
+	// like with the "| tee" of the first versions whose own stdout was discarded, and without the pipe
+	// hiding the exit code of the job
+	output, err := osutils.CreateFile(j.outputFilePath())
+	if err != nil {
+		result.Err = err
+		result.ExitCode = -1
+		return
+	}
+	defer output.Close()
+	remoteJob, transfers := j.withTransfers()
+	var returns []string
+	for _, returnPath := range returnPaths {
+		returns = append(returns, remoteJob.render(returnPath))
+	}
+	jobLogger := logger.Log.WithFields(logrus.Fields{"job": j.index, "values": j.values, "attempt": result.Attempts})
+	if slot.host != "" {
+		jobLogger = jobLogger.WithField("host", slot.host)
+	}
+	jobLogger.Info("Job started")
+	attemptStart := time.Now()
+	outcome := jobExecutor.Run(executor.Job{
+		Index:     j.index,
+		Command:   remoteJob.renderCommand(),
+		Host:      slot.host,
+		Stdout:    output,
+		Timeout:   jobTimeout,
+		Cancel:    cancel,
+		Transfers: transfers,
+		Returns:   returns,
+		ReturnDir: j.returnedFilesDirectory(),
+	})
+	if outcome.Host != "" {
+		result.Host = outcome.Host
+	}
+	result.ExitCode = outcome.ExitCode
+	result.Signal = outcome.Signal
+	result.TimedOut = outcome.TimedOut
+	result.LimitExceeded = outcome.LimitExceeded
+	result.Err = outcome.Err
+	jobLogger = jobLogger.WithFields(logrus.Fields{"exit_code": result.ExitCode, "duration": time.Since(attemptStart).String()})
+	if result.LimitExceeded != "" {
+		jobLogger.WithField("limit", result.LimitExceeded).Errorf("Job %d was killed after exceeding its %s limit", result.Index, result.LimitExceeded)
+	} else if result.Err != nil {
+		jobLogger.WithError(result.Err).Error("Job failed")
+	} else {
+		jobLogger.Info("Job finished")
+	}
+}
+
+func executeParallel(jobs []job) ([]jobutils.Result, error) {
+	var progress *jobutils.Progress
+	if showProgress {
+		progress = startProgress(len(jobs))
+		defer stopProgress(progress)
+	}
+	var jobLog *jobutils.JobLog
+	if jobLogPath != "" {
+		var err error
+		openJobLog := jobutils.CreateJobLog
+		if isResuming() {
+			openJobLog = jobutils.OpenJobLogForAppend
+		}
+		if jobLog, err = openJobLog(jobLogPath); err != nil {
+			return nil, err
+		}
+		defer jobLog.Close()
+	}
+	// the cgroups are only touched once the jobs really run, not by --dry-run or a declined confirmation
+	restoreCgroups, err := setupJobCgroups()
+	if err != nil {
+		return nil, err
+	}
+	defer restoreCgroups()
+	jobExecutor, err := newExecutor()
+	if err != nil {
+		return nil, err
+	}
+	defer jobExecutor.Close()
+	// every running job holds a slot, there are as many slots as jobs allowed to run at once
+	jobSlots := newJobSlots(len(jobs))
+	slots := make(chan jobSlot, len(jobSlots))
+	for _, slot := range jobSlots {
+		slots <- slot
+	}
+	startLimiter := jobutils.NewStartLimiter(startRatePerSecond, startRateBurst, startDelay)
+	resourceThrottle := jobutils.NewResourceThrottle(maxLoad, minFreeMemory)
+	// once halted no job is dispatched, and with --halt now the running jobs are killed through killRunning.
+	// Closing halted also ends the waits of the job starts
+	halted := make(chan struct{})
+	killRunning := make(chan struct{})
+	isHalted := func() bool {
+		select {
+		case <-halted:
+			return true
+		default:
+			return false
+		}
+	}
+
+	type finishedJob struct {
+		position int
+		result   jobutils.Result
+	}
+	ch := make(chan finishedJob)
+	// a job is ready once the jobs it needs succeeded, the dispatcher starts the ready jobs in order
+	positions := make(map[int]int, len(jobs))
+	for position, j := range jobs {
+		positions[j.index] = position
+	}
+	waitingFor := make([]int, len(jobs))
+	dependents := make([][]int, len(jobs))
+	for position, j := range jobs {
+		for _, index := range j.needs {
+			// a needed job which isn't part of this run finished in a previous run
+			if needed, found := positions[index]; found {
+				waitingFor[position]++
+				dependents[needed] = append(dependents[needed], position)
+			}
+		}
+	}
+	ready := make(chan int, len(jobs))
+	// a job is released once it is ready or skipped, ready is closed once every job is released
+	released := make([]bool, len(jobs))
+	releasedCount := 0
+	release := func(position int) {
+		released[position] = true
+		releasedCount++
+		if releasedCount == len(jobs) {
+			close(ready)
+		}
+	}
+	for position := range jobs {
+		if waitingFor[position] == 0 {
+			ready <- position
+			release(position)
+		}
+	}
+	if len(jobs) == 0 {
+		close(ready)
+	}
+	go func() {
+		for position := range ready {
+			j := jobs[position]
+			slot := <-slots
+			// a job taken after the halt is skipped without waiting for the start limits
+			if !isHalted() {
+				resourceThrottle.Wait(halted)
+				startLimiter.Wait(halted)
+			}
+			if isHalted() {
+				slots <- slot
+				ch <- finishedJob{position: position, result: jobutils.Result{
+					Index: j.index, Label: j.label, Values: j.values, Command: j.renderCommand(), SkipReason: haltedSkipReason,
+				}}
+				continue
+			}
+			go func(position int, j job, slot jobSlot) {
+				if progress != nil {
+					progress.JobStarted()
+				}
+				// Run command in paralllel report the result to channel [execute/wait]
+				result := runJob(jobExecutor, j, slot, killRunning)
+				if progress != nil {
+					progress.JobFinished(result.Duration, !result.Succeeded())
+				}
+				if jobLog != nil {
+					// the details are written first, a job in the job log always has them
+					if err := jobutils.WriteJobLogDetails(j.detailsFilePath(), jobutils.NewJobLogDetails(result)); err != nil {
+						logger.Log.Errorf("Failed to write the details of job %d next to the job log: %v", result.Index, err)
+					}
+					if err := jobLog.Write(jobutils.NewJobLogEntry(result)); err != nil {
+						logger.Log.Errorf("Failed to write job %d to the job log: %v", result.Index, err)
+					}
+				}
+				slots <- slot
+				ch <- finishedJob{position: position, result: result}
+			}(position, j, slot)
+		}
+	}()
+	// wait for all the goroutines to complete
+	results := make([]jobutils.Result, len(jobs))
+	collected := 0
+	var collect func(position int, result jobutils.Result)
+	collect = func(position int, result jobutils.Result) {
+		results[position] = result
+		collected++
+		for _, dependent := range dependents[position] {
+			if released[dependent] {
+				continue
+			}
+			if result.Succeeded() {
+				waitingFor[dependent]--
+				if waitingFor[dependent] == 0 {
+					ready <- dependent
+					release(dependent)
+				}
+				continue
+			}
+			// the dependents of a failed job never run, nor do their own dependents
+			reason := upstreamFailedSkipReason
+			if result.SkipReason == haltedSkipReason {
+				reason = haltedSkipReason
+			}
+			release(dependent)
+			d := jobs[dependent]
+			if reason == upstreamFailedSkipReason {
+				logger.Log.Warnf("Job %d (%s) skipped: %s, job %d (%s) did not succeed", d.index, d.label, upstreamFailedSkipReason, result.Index, result.Label)
+			}
+			collect(dependent, jobutils.Result{Index: d.index, Label: d.label, Values: d.values, Command: d.renderCommand(), SkipReason: reason})
+		}
+	}
+	succeeded, failed := 0, 0
+	var haltErr *haltError
+	for collected < len(jobs) {
+		finished := <-ch
+		collect(finished.position, finished.result)
+		if finished.result.Skipped() || haltErr != nil {
+			continue
+		}
+		if finished.result.Succeeded() {
+			succeeded++
+		} else {
+			failed++
+		}
+		if haltPolicy.ShouldHalt(succeeded, failed, len(jobs)) {
+			haltErr = &haltError{trigger: finished.result}
+			logger.Log.Warnf("Halt policy %q reached by job %d, no more jobs are started", haltPolicyInput, finished.result.Index)
+			close(halted)
+			if haltPolicy.When == jobutils.HaltNow {
+				close(killRunning)
+			}
+		}
+	}
+	if haltErr != nil {
+		return results, haltErr
+	}
+	return results, nil
+}
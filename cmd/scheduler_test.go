@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tamirdavid/paralix/lib/executor"
+	jobutils "github.com/tamirdavid/paralix/lib/jobUtils"
+)
+
+// useFakeExecutor runs the jobs of a test with run instead of a shell, one job at a time,
+// and removes the executor and restores the run settings once the test is done
+func useFakeExecutor(t *testing.T, halt string, run func(job executor.Job) executor.Outcome) {
+	t.Helper()
+	savedExecutor, savedOutputs, savedParallel := executorName, outputfilesDir, parallelJobs
+	savedRate, savedBurst, savedDelay := startRatePerSecond, startRateBurst, startDelay
+	savedHaltInput, savedHalt := haltPolicyInput, haltPolicy
+	t.Cleanup(func() {
+		executor.Unregister("fake")
+		executorName, outputfilesDir, parallelJobs = savedExecutor, savedOutputs, savedParallel
+		startRatePerSecond, startRateBurst, startDelay = savedRate, savedBurst, savedDelay
+		haltPolicyInput, haltPolicy = savedHaltInput, savedHalt
+	})
+	executor.Register("fake", func(executor.Options) (executor.Executor, error) {
+		return executor.Func(run), nil
+	})
+	executorName = "fake"
+	outputfilesDir = t.TempDir()
+	parallelJobs = 1
+	startRatePerSecond, startRateBurst, startDelay = 0, 1, 0
+	haltPolicyInput = halt
+	var err error
+	if haltPolicy, err = jobutils.ParseHaltPolicy(halt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testJobs(count int) []job {
+	var jobs []job
+	for index := 1; index <= count; index++ {
+		j := newJob(index, map[string]string{"N": strconv.Itoa(index)}, []string{"N"})
+		j.template = "job <N>"
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+func failJobs(indexes ...int) func(job executor.Job) executor.Outcome {
+	return func(job executor.Job) executor.Outcome {
+		for _, index := range indexes {
+			if job.Index == index {
+				return executor.Outcome{ExitCode: 1, Err: errors.New("exit status 1")}
+			}
+		}
+		return executor.Outcome{}
+	}
+}
+
+func TestExecuteParallelHaltSkipsStartLimits(t *testing.T) {
+	useFakeExecutor(t, "soon,fail=1", failJobs(1))
+	// a start every 10 seconds, the jobs left after the halt must not wait for it
+	startRatePerSecond = 0.1
+	begin := time.Now()
+	results, err := executeParallel(testJobs(5))
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Errorf("halted run took %v, want the jobs left to be skipped without waiting", elapsed)
+	}
+	var haltErr *haltError
+	if !errors.As(err, &haltErr) {
+		t.Fatalf("executeParallel() error = %v, want a halt", err)
+	}
+	for _, result := range results[1:] {
+		if result.SkipReason != haltedSkipReason {
+			t.Errorf("job %d skip reason = %q, want %q", result.Index, result.SkipReason, haltedSkipReason)
+		}
+	}
+}
+
+// recordedRuns records the indexes of the jobs that ran, in the order they started
+type recordedRuns struct {
+	mu      sync.Mutex
+	indexes []int
+}
+
+func (r *recordedRuns) record(run func(job executor.Job) executor.Outcome) func(job executor.Job) executor.Outcome {
+	return func(job executor.Job) executor.Outcome {
+		r.mu.Lock()
+		r.indexes = append(r.indexes, job.Index)
+		r.mu.Unlock()
+		return run(job)
+	}
+}
+
+func (r *recordedRuns) ran() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.indexes...)
+}
+
+func skipReasons(results []jobutils.Result) map[int]string {
+	reasons := make(map[int]string)
+	for _, result := range results {
+		if result.Skipped() {
+			reasons[result.Index] = result.SkipReason
+		}
+	}
+	return reasons
+}
+
+func TestExecuteParallelDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		needs map[int][]int
+		fail  []int
+		// the jobs that must have run, in start order when parallel is 1
+		wantRan     []int
+		wantSkipped map[int]string
+	}{
+		{
+			name:        "failed root skips its transitive dependents",
+			needs:       map[int][]int{2: {1}, 3: {2}, 4: {3}},
+			fail:        []int{1},
+			wantRan:     []int{1, 5},
+			wantSkipped: map[int]string{2: upstreamFailedSkipReason, 3: upstreamFailedSkipReason, 4: upstreamFailedSkipReason},
+		},
+		{
+			name:        "diamond runs the join once both sides succeeded",
+			needs:       map[int][]int{2: {1}, 3: {1}, 4: {2, 3}},
+			wantRan:     []int{1, 5, 2, 3, 4},
+			wantSkipped: map[int]string{},
+		},
+		{
+			name:        "diamond with a failed side skips the join once",
+			needs:       map[int][]int{2: {1}, 3: {1}, 4: {2, 3}},
+			fail:        []int{2},
+			wantRan:     []int{1, 5, 2, 3},
+			wantSkipped: map[int]string{4: upstreamFailedSkipReason},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := &recordedRuns{}
+			useFakeExecutor(t, "never", runs.record(failJobs(tt.fail...)))
+			jobs := testJobs(5)
+			for index, needs := range tt.needs {
+				jobs[index-1].needs = needs
+			}
+			results, err := executeParallel(jobs)
+			if err != nil {
+				t.Fatalf("executeParallel() error = %v", err)
+			}
+			if got := runs.ran(); !reflect.DeepEqual(got, tt.wantRan) {
+				t.Errorf("jobs ran in the order %v, want %v", got, tt.wantRan)
+			}
+			if got := skipReasons(results); !reflect.DeepEqual(got, tt.wantSkipped) {
+				t.Errorf("skipped jobs = %v, want %v", got, tt.wantSkipped)
+			}
+			for position, result := range results {
+				if result.Index != jobs[position].index {
+					t.Errorf("result %d is of job %d, want job %d", position, result.Index, jobs[position].index)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteParallelHaltInDAG(t *testing.T) {
+	// job 1 fails and halts the run while job 2 is still running, job 3 is ready only once job 2 succeeded
+	runs := &recordedRuns{}
+	useFakeExecutor(t, "soon,fail=1", runs.record(func(job executor.Job) executor.Outcome {
+		if job.Index == 2 {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return failJobs(1)(job)
+	}))
+	parallelJobs = 2
+	jobs := testJobs(5)
+	jobs[2].needs = []int{2}
+	jobs[3].needs = []int{3}
+	jobs[4].needs = []int{1}
+	results, err := executeParallel(jobs)
+	var haltErr *haltError
+	if !errors.As(err, &haltErr) || haltErr.trigger.Index != 1 {
+		t.Fatalf("executeParallel() error = %v, want a halt by job 1", err)
+	}
+	if got := runs.ran(); len(got) != 2 {
+		t.Errorf("jobs %v ran, want only jobs 1 and 2", got)
+	}
+	// the dependents of a job skipped by the halt are skipped by the halt too, not for a failed upstream
+	want := map[int]string{3: haltedSkipReason, 4: haltedSkipReason, 5: upstreamFailedSkipReason}
+	if got := skipReasons(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("skipped jobs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/tamirdavid/paralix/lib/distributed"
+)
+
+var listenAddress string
+var leaseDuration time.Duration
+var coordinatorToken string
+
+// set by paralix serve, the coordinator executor queues the jobs for the workers of paralix serve only
+var serving bool
+
+// the flags of paralix command choosing where and how the jobs run, the workers run them with bash and no limits
+var serveUnsupportedFlags []string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a command with N args on the workers connected with paralix worker.",
+	Long: `Run a command with N args like paralix command, but instead of running the jobs itself
+paralix queues them for the workers started with 'paralix worker --connect URL' to pull over HTTP.
+A job whose worker disappears is queued again once its lease expires.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range serveUnsupportedFlags {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s can't be used with paralix serve, its jobs run on the workers", name)
+			}
+		}
+		if leaseDuration < distributed.MinLeaseDuration {
+			return fmt.Errorf("--lease must be at least %v, the workers send a few heartbeats per lease", distributed.MinLeaseDuration)
+		}
+		serving = true
+		defer func() { serving = false }()
+		executorName = "coordinator"
+		return commandCmd.RunE(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	addRunFlags(serveCmd.Flags())
+	serveCmd.Flags().StringVar(&listenAddress, "listen", "127.0.0.1:8080", "Address to listen on for workers, the workers of other machines need an address like :8080 [Example --listen :8080 --token ...]")
+	serveCmd.Flags().DurationVar(&leaseDuration, "lease", distributed.DefaultLeaseDuration, "A job is queued again when its worker sends no heartbeat for this long")
+	serveCmd.Flags().StringVar(&coordinatorToken, "token", "", "Shared secret the workers must send with their --token, also read from PARALIX_TOKEN")
+	// the unsupported flags are hidden and rejected with a clear error instead of an unknown flag
+	unsupported := pflag.NewFlagSet("unsupported", pflag.ContinueOnError)
+	addExecutorFlags(unsupported)
+	addRemoteFlags(unsupported)
+	addJobLimitFlags(unsupported)
+	unsupported.VisitAll(func(f *pflag.Flag) {
+		f.Hidden = true
+		serveUnsupportedFlags = append(serveUnsupportedFlags, f.Name)
+	})
+	serveCmd.Flags().AddFlagSet(unsupported)
+	serveCmd.MarkFlagRequired("output")
+	serveCmd.MarkFlagRequired("execute")
+}
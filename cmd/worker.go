@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tamirdavid/paralix/lib/distributed"
+	"github.com/tamirdavid/paralix/lib/executor"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+)
+
+var coordinatorURL string
+var workerName string
+var workerJobs int
+var workerRetryFor time.Duration
+var workerToken string
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Run the jobs of a paralix serve coordinator.",
+	Long:  `Pull jobs from the coordinator started with paralix serve, run them locally and send their results back until the coordinator has no more jobs.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if workerJobs <= 0 {
+			return fmt.Errorf("--jobs [-j] should be at least 1 but got %d", workerJobs)
+		}
+		if workerName == "" {
+			hostname, _ := os.Hostname()
+			workerName = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+		localExecutor := executor.NewLocal(osutils.JobLimits{}, "")
+		// every job slot of the worker pulls its own jobs
+		errs := make(chan error, workerJobs)
+		for slot := 1; slot <= workerJobs; slot++ {
+			worker := distributed.NewWorker(coordinatorURL, fmt.Sprintf("%s/%d", workerName, slot), localExecutor)
+			worker.RetryFor = workerRetryFor
+			worker.Token = workerToken
+			go func() {
+				errs <- worker.Run()
+			}()
+		}
+		var firstErr error
+		for i := 0; i < workerJobs; i++ {
+			if err := <-errs; err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+	workerCmd.Flags().StringVar(&coordinatorURL, "connect", "", "URL of the paralix serve coordinator [Example --connect http://build-1:8080]")
+	workerCmd.Flags().StringVar(&workerName, "name", "", "Name of the worker in the results, by default the hostname and pid")
+	workerCmd.Flags().IntVarP(&workerJobs, "jobs", "j", 1, "Number of jobs the worker runs at once")
+	workerCmd.Flags().DurationVar(&workerRetryFor, "retry-for", distributed.DefaultRetryFor, "Keep retrying an unreachable coordinator for this long before giving up")
+	workerCmd.Flags().StringVar(&workerToken, "token", "", "Shared secret of the coordinator started with paralix serve --token, also read from PARALIX_TOKEN")
+	workerCmd.MarkFlagRequired("connect")
+}
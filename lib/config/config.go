@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ProjectFileName = ".paralix.yaml"
+	EnvPrefix       = "PARALIX_"
+)
+
+// Values maps flag names to their values, flags that can be repeated have several values
+type Values map[string][]string
+
+// File is a config file, the defaults apply to every run and a profile is applied over them when it is selected
+type File struct {
+	Path     string
+	Defaults Values
+	Profiles map[string]Values
+}
+
+type rawFile struct {
+	Defaults map[string]interface{}            `yaml:"defaults"`
+	Profiles map[string]map[string]interface{} `yaml:"profiles"`
+}
+
+func UserFilePath() (string, error) {
+	// ~/.config/paralix/config.yaml on linux, XDG_CONFIG_HOME is honored
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "paralix", "config.yaml"), nil
+}
+
+func FindProjectFile(dir string) string {
+	// the project file is looked up from the directory up to the root, like .gitignore
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func LoadFile(path string) (*File, error) {
+	content, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw rawFile
+	decoder := yaml.NewDecoder(strings.NewReader(string(content)))
+	// a misspelled top level key would silently do nothing
+	decoder.KnownFields(true)
+	// an empty file decodes to io.EOF
+	if err := decoder.Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	file := &File{Path: path, Profiles: make(map[string]Values)}
+	if file.Defaults, err = toValues(raw.Defaults); err != nil {
+		return nil, fmt.Errorf("%s: defaults: %w", path, err)
+	}
+	for name, profile := range raw.Profiles {
+		if file.Profiles[name], err = toValues(profile); err != nil {
+			return nil, fmt.Errorf("%s: profile %s: %w", path, name, err)
+		}
+	}
+	return file, nil
+}
+
+func toValues(raw map[string]interface{}) (Values, error) {
+	values := make(Values)
+	for key, value := range raw {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				str, err := scalarString(key, item)
+				if err != nil {
+					return nil, err
+				}
+				values[key] = append(values[key], str)
+			}
+		default:
+			str, err := scalarString(key, v)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = []string{str}
+		}
+	}
+	return values, nil
+}
+
+func scalarString(key string, value interface{}) (string, error) {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", fmt.Errorf("%s should be a value or a list of values", key)
+	case nil:
+		return "", nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// Layers returns the values of the file that apply to the profile, from the lowest precedence to the highest
+func (f *File) Layers(profile string) []Values {
+	if f == nil {
+		return nil
+	}
+	layers := []Values{f.Defaults}
+	if values, ok := f.Profiles[profile]; ok && profile != "" {
+		layers = append(layers, values)
+	}
+	return layers
+}
+
+func (f *File) HasProfile(profile string) bool {
+	if f == nil {
+		return false
+	}
+	_, ok := f.Profiles[profile]
+	return ok
+}
+
+func (f *File) Keys() []string {
+	if f == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for key := range f.Defaults {
+		seen[key] = true
+	}
+	for _, values := range f.Profiles {
+		for key := range values {
+			seen[key] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func EnvName(flagName string) string {
+	// --job-memory is PARALIX_JOB_MEMORY
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+func EnvValues(flagNames []string, getenv func(string) string) Values {
+	values := make(Values)
+	for _, name := range flagNames {
+		if value := getenv(EnvName(name)); value != "" {
+			values[name] = []string{value}
+		}
+	}
+	return values
+}
+
+// Resolve merges the layers, a flag takes its values from the last layer that sets it
+func Resolve(layers ...Values) Values {
+	resolved := make(Values)
+	for _, layer := range layers {
+		for key, values := range layer {
+			resolved[key] = values
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir string, content string) string {
+	path := filepath.Join(dir, ProjectFileName)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *File
+		wantErr bool
+	}{
+		{
+			name:    "defaults and profiles",
+			content: "defaults:\n  jobs: 8\n  timeout: 30s\n  job-cpu: 0.5\n  summary: false\nprofiles:\n  prod-sweep:\n    ssh-login: a,b\n    return: [out.txt, logs]\n",
+			want: &File{
+				Defaults: Values{"jobs": {"8"}, "timeout": {"30s"}, "job-cpu": {"0.5"}, "summary": {"false"}},
+				Profiles: map[string]Values{"prod-sweep": {"ssh-login": {"a,b"}, "return": {"out.txt", "logs"}}},
+			},
+		},
+		{name: "empty", content: "", want: &File{Defaults: Values{}, Profiles: map[string]Values{}}},
+		{name: "nested value", content: "defaults:\n  jobs:\n    max: 3\n", wantErr: true},
+		{name: "unknown section", content: "default:\n  jobs: 3\n", wantErr: true},
+		{name: "invalid yaml", content: "defaults: [", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.content)
+			got, err := LoadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.want != nil {
+				tt.want.Path = path
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if got != nil || err != nil {
+		t.Errorf("LoadFile() = %v, %v, want no file and no error", got, err)
+	}
+	// a missing file has no values and no profiles
+	if got.Layers("prod") != nil || got.HasProfile("prod") || got.Keys() != nil {
+		t.Errorf("a missing file should have no values")
+	}
+}
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	os.MkdirAll(nested, 0755)
+	path := writeConfig(t, root, "defaults:\n  jobs: 1\n")
+	if got := FindProjectFile(nested); got != path {
+		t.Errorf("FindProjectFile() = %q, want %q", got, path)
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "defaults:\n  jobs: 2\n  format: json\n  output: out\nprofiles:\n  prod:\n    jobs: 16\n")
+	file, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	env := map[string]string{"PARALIX_FORMAT": "jsonl", "PARALIX_JOB_MEMORY": "1G"}
+	envValues := EnvValues([]string{"jobs", "format", "job-memory"}, func(name string) string { return env[name] })
+	user := Values{"output": {"user-out"}, "timeout": {"1m"}}
+	got := Resolve(append(append([]Values{user}, file.Layers("prod")...), envValues)...)
+	want := Values{"jobs": {"16"}, "format": {"jsonl"}, "output": {"out"}, "timeout": {"1m"}, "job-memory": {"1G"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(file.Keys(), []string{"format", "jobs", "output"}) {
+		t.Errorf("Keys() = %v", file.Keys())
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("job-memory"); got != "PARALIX_JOB_MEMORY" {
+		t.Errorf("EnvName() = %q", got)
+	}
+}
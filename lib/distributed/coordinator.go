@@ -0,0 +1,296 @@
+package distributed
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tamirdavid/paralix/lib/executor"
+	"github.com/tamirdavid/paralix/lib/logger"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+const (
+	DefaultLeaseDuration = 30 * time.Second
+	// the shortest lease paralix serve accepts, the workers send a few heartbeats per lease
+	MinLeaseDuration = time.Second
+	// a lease request waits this long for a task before the worker is told to ask again
+	defaultLeasePollTimeout = 20 * time.Second
+	// time given to the workers to hear that the run is over before the coordinator stops listening
+	shutdownGracePeriod = time.Second
+)
+
+type task struct {
+	Task
+	result   chan TaskResult
+	leaseID  string
+	worker   string
+	deadline time.Time
+}
+
+// Coordinator holds the queue of the jobs and hands them to the workers that poll it over HTTP, a job whose
+// worker stops sending heartbeats goes back to the queue. It is the executor of paralix serve.
+type Coordinator struct {
+	mu               sync.Mutex
+	pending          []*task
+	leased           map[string]*task
+	nextLeaseID      int
+	done             bool
+	changed          chan struct{}
+	leaseDuration    time.Duration
+	leasePollTimeout time.Duration
+	stopJanitor      chan struct{}
+	server           *http.Server
+	// the workers must send this token on every request, when it's set
+	Token string
+}
+
+func init() {
+	executor.Register("coordinator", func(options executor.Options) (executor.Executor, error) {
+		coordinator := NewCoordinator(options.LeaseDuration)
+		coordinator.Token = options.Token
+		if err := coordinator.Listen(options.ListenAddress); err != nil {
+			coordinator.Close()
+			return nil, err
+		}
+		return coordinator, nil
+	})
+}
+
+func NewCoordinator(leaseDuration time.Duration) *Coordinator {
+	// the expired leases are looked for 4 times per lease, a shorter lease would stop the ticker
+	if leaseDuration/4 <= 0 {
+		leaseDuration = DefaultLeaseDuration
+	}
+	c := &Coordinator{
+		leased:           make(map[string]*task),
+		changed:          make(chan struct{}),
+		leaseDuration:    leaseDuration,
+		leasePollTimeout: defaultLeasePollTimeout,
+		stopJanitor:      make(chan struct{}),
+	}
+	go c.requeueExpiredLeases()
+	return c
+}
+
+func (c *Coordinator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(leasePath, c.handleLease)
+	mux.HandleFunc(heartbeatPath, c.handleHeartbeat)
+	mux.HandleFunc(resultPath, c.handleResult)
+	if c.Token == "" {
+		return mux
+	}
+	// anyone allowed to lease a job sees its command and anyone allowed to post a result makes it up
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(tokenHeader)), []byte(bearer(c.Token))) != 1 {
+			http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func bearer(token string) string {
+	return "Bearer " + token
+}
+
+func (c *Coordinator) Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	logger.Log.Infof("Coordinator is listening on %s, waiting for workers", listener.Addr())
+	if c.Token == "" && !isLoopback(listener.Addr()) {
+		logger.Log.Warnf("Coordinator listens on %s without a token, anyone who reaches it can take the jobs and send made up results", listener.Addr())
+	}
+	c.server = &http.Server{Handler: c.Handler()}
+	go c.server.Serve(listener)
+	return nil
+}
+
+func isLoopback(address net.Addr) bool {
+	tcpAddress, ok := address.(*net.TCPAddr)
+	return ok && tcpAddress.IP.IsLoopback()
+}
+
+// notify wakes up the lease requests waiting for a task, the caller holds the lock
+func (c *Coordinator) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *Coordinator) Run(job executor.Job) executor.Outcome {
+	t := &task{Task: Task{Index: job.Index, Command: job.Command, TimeoutSeconds: job.Timeout.Seconds()}, result: make(chan TaskResult, 1)}
+	c.mu.Lock()
+	c.pending = append(c.pending, t)
+	c.notify()
+	c.mu.Unlock()
+	select {
+	case result := <-t.result:
+		io.WriteString(job.Stdout, result.Stdout)
+		outcome := executor.Outcome{Host: result.Worker, ExitCode: result.ExitCode, Signal: result.Signal, TimedOut: result.TimedOut}
+		if result.Error != "" {
+			outcome.Err = errors.New(result.Error)
+		}
+		return outcome
+	case <-job.Cancel:
+		// the worker running the job finds out on its next heartbeat and kills it
+		c.mu.Lock()
+		c.removeTask(t)
+		worker := t.worker
+		c.mu.Unlock()
+		return executor.Outcome{Host: worker, ExitCode: -1, Err: paralixutils.ErrCanceled}
+	}
+}
+
+func (c *Coordinator) removeTask(t *task) {
+	delete(c.leased, t.leaseID)
+	for i, pending := range c.pending {
+		if pending == t {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Coordinator) Close() error {
+	c.mu.Lock()
+	alreadyDone := c.done
+	c.done = true
+	c.notify()
+	c.mu.Unlock()
+	if alreadyDone {
+		return nil
+	}
+	close(c.stopJanitor)
+	if c.server == nil {
+		return nil
+	}
+	// the workers asking for a task in the grace period are told that the run is over
+	time.Sleep(shutdownGracePeriod)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
+	return c.server.Shutdown(ctx)
+}
+
+func (c *Coordinator) requeueExpiredLeases() {
+	ticker := time.NewTicker(c.leaseDuration / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopJanitor:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for leaseID, t := range c.leased {
+				if now.After(t.deadline) {
+					logger.Log.Warnf("Worker %s stopped sending heartbeats, job %d is queued again", t.worker, t.Index)
+					delete(c.leased, leaseID)
+					// the job goes to the front of the queue, it was started before the pending ones
+					c.pending = append([]*task{t}, c.pending...)
+					c.notify()
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *Coordinator) handleLease(w http.ResponseWriter, r *http.Request) {
+	var request LeaseRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	timeout := time.NewTimer(c.leasePollTimeout)
+	defer timeout.Stop()
+	for {
+		c.mu.Lock()
+		if c.done {
+			c.mu.Unlock()
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		if len(c.pending) > 0 {
+			t := c.pending[0]
+			c.pending = c.pending[1:]
+			c.nextLeaseID++
+			t.leaseID = strconv.Itoa(c.nextLeaseID)
+			t.worker = request.Worker
+			t.deadline = time.Now().Add(c.leaseDuration)
+			c.leased[t.leaseID] = t
+			c.mu.Unlock()
+			logger.Log.Infof("Job %d leased to worker %s", t.Index, request.Worker)
+			writeResponse(w, Lease{ID: t.leaseID, Task: t.Task, LeaseSeconds: c.leaseDuration.Seconds()})
+			return
+		}
+		changed := c.changed
+		c.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timeout.C:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (c *Coordinator) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	var request HeartbeatRequest
+	if !decodeRequest(w, r, &request) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.leased[request.LeaseID]
+	if !ok {
+		// the lease expired or the job was canceled, the worker must drop the job
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	t.deadline = time.Now().Add(c.leaseDuration)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Coordinator) handleResult(w http.ResponseWriter, r *http.Request) {
+	var result TaskResult
+	if !decodeRequest(w, r, &result) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.leased[result.LeaseID]
+	if !ok {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+	delete(c.leased, result.LeaseID)
+	t.result <- result
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,157 @@
+package distributed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tamirdavid/paralix/lib/executor"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+func newTestCoordinator(t *testing.T, leaseDuration time.Duration) (*Coordinator, *httptest.Server) {
+	coordinator := NewCoordinator(leaseDuration)
+	coordinator.leasePollTimeout = 100 * time.Millisecond
+	server := httptest.NewServer(coordinator.Handler())
+	t.Cleanup(server.Close)
+	return coordinator, server
+}
+
+func startWorker(server *httptest.Server, name string, jobExecutor executor.Executor) chan error {
+	done := make(chan error, 1)
+	worker := NewWorker(server.URL, name, jobExecutor)
+	worker.RetryFor = time.Second
+	go func() {
+		done <- worker.Run()
+	}()
+	return done
+}
+
+func TestCoordinatorWithWorkers(t *testing.T) {
+	coordinator, server := newTestCoordinator(t, time.Second)
+	echo := executor.Func(func(job executor.Job) executor.Outcome {
+		fmt.Fprint(job.Stdout, job.Command)
+		if strings.HasSuffix(job.Command, "fail") {
+			return executor.Outcome{ExitCode: 2, Err: errors.New("exit status 2")}
+		}
+		return executor.Outcome{}
+	})
+	workers := []chan error{startWorker(server, "w1", echo), startWorker(server, "w2", echo)}
+	jobs := []string{"job 1", "job 2", "job 3 fail", "job 4"}
+	outcomes := make([]executor.Outcome, len(jobs))
+	stdouts := make([]bytes.Buffer, len(jobs))
+	var wg sync.WaitGroup
+	for i, command := range jobs {
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			outcomes[i] = coordinator.Run(executor.Job{Index: i + 1, Command: command, Stdout: &stdouts[i]})
+		}(i, command)
+	}
+	wg.Wait()
+	for i, command := range jobs {
+		if stdouts[i].String() != command {
+			t.Errorf("job %d stdout = %q, want %q", i+1, stdouts[i].String(), command)
+		}
+		if outcomes[i].Host != "w1" && outcomes[i].Host != "w2" {
+			t.Errorf("job %d ran on %q", i+1, outcomes[i].Host)
+		}
+	}
+	if outcomes[2].ExitCode != 2 || outcomes[2].Err == nil || outcomes[0].Err != nil {
+		t.Errorf("outcomes = %+v", outcomes)
+	}
+	coordinator.Close()
+	for _, worker := range workers {
+		if err := <-worker; err != nil {
+			t.Errorf("worker Run() error = %v", err)
+		}
+	}
+}
+
+func TestCoordinatorRequeuesLostLease(t *testing.T) {
+	coordinator, server := newTestCoordinator(t, 200*time.Millisecond)
+	defer coordinator.Close()
+	result := make(chan executor.Outcome)
+	go func() {
+		result <- coordinator.Run(executor.Job{Index: 1, Command: "job", Stdout: &bytes.Buffer{}})
+	}()
+	// a worker that leases the job and disappears
+	lost := NewWorker(server.URL, "lost", nil)
+	var lease Lease
+	if status, err := lost.postOnce(leasePath, LeaseRequest{Worker: "lost"}, &lease); err != nil || status != http.StatusOK {
+		t.Fatalf("lease request = %d, %v", status, err)
+	}
+	startWorker(server, "w1", executor.Func(func(job executor.Job) executor.Outcome { return executor.Outcome{} }))
+	select {
+	case outcome := <-result:
+		if outcome.Host != "w1" || outcome.Err != nil {
+			t.Errorf("Run() = %+v, want the job to run again on w1", outcome)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the job of the lost worker was not queued again")
+	}
+	// the late result of the lost worker is refused
+	if status, _ := lost.postOnce(resultPath, TaskResult{LeaseID: lease.ID, Worker: "lost"}, nil); status != http.StatusGone {
+		t.Errorf("late result status = %d, want %d", status, http.StatusGone)
+	}
+}
+
+func TestCoordinatorCancel(t *testing.T) {
+	coordinator, server := newTestCoordinator(t, 150*time.Millisecond)
+	defer coordinator.Close()
+	started := make(chan struct{})
+	killed := make(chan struct{})
+	startWorker(server, "w1", executor.Func(func(job executor.Job) executor.Outcome {
+		close(started)
+		<-job.Cancel
+		close(killed)
+		return executor.Outcome{ExitCode: -1, Err: paralixutils.ErrCanceled}
+	}))
+	cancel := make(chan struct{})
+	go func() {
+		<-started
+		close(cancel)
+	}()
+	outcome := coordinator.Run(executor.Job{Index: 1, Command: "sleep", Stdout: &bytes.Buffer{}, Cancel: cancel})
+	if !errors.Is(outcome.Err, paralixutils.ErrCanceled) {
+		t.Errorf("Run() error = %v, want %v", outcome.Err, paralixutils.ErrCanceled)
+	}
+	select {
+	case <-killed:
+	case <-time.After(5 * time.Second):
+		t.Errorf("the worker didn't kill the canceled job")
+	}
+}
+
+func TestCoordinatorToken(t *testing.T) {
+	coordinator := NewCoordinator(time.Second)
+	coordinator.leasePollTimeout = 100 * time.Millisecond
+	coordinator.Token = "s3cret"
+	server := httptest.NewServer(coordinator.Handler())
+	defer server.Close()
+	defer coordinator.Close()
+	succeed := executor.Func(func(job executor.Job) executor.Outcome { return executor.Outcome{} })
+	if err := <-startWorker(server, "no-token", succeed); err == nil || !strings.Contains(err.Error(), "token") {
+		t.Fatalf("worker without the token Run() error = %v, want the token refused", err)
+	}
+	worker := NewWorker(server.URL, "with-token", succeed)
+	worker.Token = "s3cret"
+	done := make(chan error, 1)
+	go func() {
+		done <- worker.Run()
+	}()
+	outcome := coordinator.Run(executor.Job{Index: 1, Command: "true", Stdout: &bytes.Buffer{}})
+	if outcome.Err != nil || outcome.Host != "with-token" {
+		t.Errorf("outcome = %+v, want job 1 run by the worker with the token", outcome)
+	}
+	coordinator.Close()
+	if err := <-done; err != nil {
+		t.Errorf("worker with the token Run() error = %v", err)
+	}
+}
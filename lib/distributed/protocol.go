@@ -0,0 +1,41 @@
+package distributed
+
+// the coordinator API, every request is a POST with a JSON body
+const (
+	leasePath     = "/v1/lease"
+	heartbeatPath = "/v1/heartbeat"
+	resultPath    = "/v1/result"
+	// carries "Bearer <token>" when the coordinator has a token
+	tokenHeader = "Authorization"
+)
+
+type Task struct {
+	Index          int     `json:"index"`
+	Command        string  `json:"command"`
+	TimeoutSeconds float64 `json:"timeout_seconds,omitempty"`
+}
+
+type LeaseRequest struct {
+	Worker string `json:"worker"`
+}
+
+// Lease is a task handed to a worker, the worker keeps it by sending heartbeats before it expires
+type Lease struct {
+	ID           string  `json:"lease_id"`
+	Task         Task    `json:"task"`
+	LeaseSeconds float64 `json:"lease_seconds"`
+}
+
+type HeartbeatRequest struct {
+	LeaseID string `json:"lease_id"`
+}
+
+type TaskResult struct {
+	LeaseID  string `json:"lease_id"`
+	Worker   string `json:"worker"`
+	ExitCode int    `json:"exit_code"`
+	Signal   int    `json:"signal"`
+	TimedOut bool   `json:"timed_out"`
+	Error    string `json:"error,omitempty"`
+	Stdout   string `json:"stdout"`
+}
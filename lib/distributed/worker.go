@@ -0,0 +1,168 @@
+package distributed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tamirdavid/paralix/lib/executor"
+	"github.com/tamirdavid/paralix/lib/logger"
+)
+
+const (
+	DefaultRetryFor = 30 * time.Second
+	retryInterval   = time.Second
+)
+
+// Worker pulls jobs from a coordinator, runs them with its executor and sends the results back
+type Worker struct {
+	URL      string
+	Name     string
+	Executor executor.Executor
+	// how long the coordinator may be unreachable before the worker gives up
+	RetryFor time.Duration
+	// sent on every request when the coordinator was started with a token
+	Token  string
+	client *http.Client
+}
+
+func NewWorker(url string, name string, jobExecutor executor.Executor) *Worker {
+	// the lease requests are long polls, the timeout leaves them room to wait for a task
+	return &Worker{
+		URL:      strings.TrimSuffix(url, "/"),
+		Name:     name,
+		Executor: jobExecutor,
+		RetryFor: DefaultRetryFor,
+		client:   &http.Client{Timeout: defaultLeasePollTimeout + 10*time.Second},
+	}
+}
+
+// Run runs jobs until the coordinator says that there are no more
+func (w *Worker) Run() error {
+	for {
+		var lease Lease
+		status, err := w.post(leasePath, LeaseRequest{Worker: w.Name}, &lease)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case http.StatusGone:
+			logger.Log.Infof("Worker %s: the coordinator has no more jobs", w.Name)
+			return nil
+		case http.StatusNoContent:
+			continue
+		case http.StatusUnauthorized:
+			return fmt.Errorf("the coordinator at %s refused the worker, its token is missing or wrong", w.URL)
+		case http.StatusOK:
+			if err := w.runLease(lease); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected response %d from the coordinator", status)
+		}
+	}
+}
+
+func (w *Worker) runLease(lease Lease) error {
+	cancel := make(chan struct{})
+	var cancelOnce sync.Once
+	stopHeartbeats := make(chan struct{})
+	heartbeatsStopped := make(chan struct{})
+	go func() {
+		defer close(heartbeatsStopped)
+		// a few heartbeats per lease, so one lost request doesn't lose the job
+		leaseDuration := time.Duration(lease.LeaseSeconds * float64(time.Second))
+		if leaseDuration/3 <= 0 {
+			leaseDuration = DefaultLeaseDuration
+		}
+		ticker := time.NewTicker(leaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopHeartbeats:
+				return
+			case <-ticker.C:
+				status, err := w.postOnce(heartbeatPath, HeartbeatRequest{LeaseID: lease.ID}, nil)
+				if err == nil && status == http.StatusGone {
+					logger.Log.Warnf("Worker %s: job %d was canceled by the coordinator", w.Name, lease.Task.Index)
+					cancelOnce.Do(func() { close(cancel) })
+				}
+			}
+		}
+	}()
+	var stdout bytes.Buffer
+	outcome := w.Executor.Run(executor.Job{
+		Index:   lease.Task.Index,
+		Command: lease.Task.Command,
+		Stdout:  &stdout,
+		Timeout: time.Duration(lease.Task.TimeoutSeconds * float64(time.Second)),
+		Cancel:  cancel,
+	})
+	close(stopHeartbeats)
+	<-heartbeatsStopped
+	result := TaskResult{
+		LeaseID:  lease.ID,
+		Worker:   w.Name,
+		ExitCode: outcome.ExitCode,
+		Signal:   outcome.Signal,
+		TimedOut: outcome.TimedOut,
+		Stdout:   stdout.String(),
+	}
+	if outcome.Err != nil {
+		result.Error = outcome.Err.Error()
+	}
+	status, err := w.post(resultPath, result, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusGone {
+		logger.Log.Warnf("Worker %s: the coordinator took job %d back, its result was dropped", w.Name, lease.Task.Index)
+	}
+	return nil
+}
+
+// post retries while the coordinator is unreachable, up to RetryFor
+func (w *Worker) post(path string, request interface{}, response interface{}) (int, error) {
+	start := time.Now()
+	for {
+		status, err := w.postOnce(path, request, response)
+		if err == nil {
+			return status, nil
+		}
+		if time.Since(start) > w.RetryFor {
+			return 0, fmt.Errorf("the coordinator at %s is unreachable: %w", w.URL, err)
+		}
+		logger.Log.Warnf("Worker %s: %v, retrying", w.Name, err)
+		time.Sleep(retryInterval)
+	}
+}
+
+func (w *Worker) postOnce(path string, request interface{}, response interface{}) (int, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+	httpRequest, err := http.NewRequest(http.MethodPost, w.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if w.Token != "" {
+		httpRequest.Header.Set(tokenHeader, bearer(w.Token))
+	}
+	httpResponse, err := w.client.Do(httpRequest)
+	if err != nil {
+		return 0, err
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode == http.StatusOK && response != nil {
+		if err := json.NewDecoder(httpResponse.Body).Decode(response); err != nil {
+			return 0, err
+		}
+	}
+	return httpResponse.StatusCode, nil
+}
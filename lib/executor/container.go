@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+)
+
+// the container runtimes looked up on the PATH, in order of preference
+var containerRuntimes = []string{"docker", "podman"}
+
+// environment variables that describe the host and would break the container, they are not passed through
+var hostOnlyEnv = map[string]bool{"PATH": true, "HOME": true, "HOSTNAME": true, "PWD": true, "OLDPWD": true, "SHLVL": true, "_": true}
+
+// Container runs every job with sh in a new container of the image, the workspace is mounted at the
+// same path and is the working directory, and the environment of paralix is passed through
+type Container struct {
+	runtime   string
+	image     string
+	workspace string
+	limits    osutils.JobLimits
+}
+
+func FindContainerRuntime(runtime string) (string, error) {
+	if runtime != "" {
+		return exec.LookPath(runtime)
+	}
+	for _, candidate := range containerRuntimes {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("none of %v was found on the PATH", containerRuntimes)
+}
+
+func NewContainer(runtime string, image string, workspace string, limits osutils.JobLimits) (*Container, error) {
+	if image == "" {
+		return nil, errors.New("the container executor needs an image")
+	}
+	runtimePath, err := FindContainerRuntime(runtime)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == "" {
+		if workspace, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+	return &Container{runtime: runtimePath, image: image, workspace: workspace, limits: limits}, nil
+}
+
+func (e *Container) runArgs(name string, script string) []string {
+	args := []string{"run", "--name", name, "-v", e.workspace + ":" + e.workspace, "-w", e.workspace}
+	for _, variable := range os.Environ() {
+		key := strings.SplitN(variable, "=", 2)[0]
+		if !hostOnlyEnv[key] {
+			// without a value the runtime copies the variable from its own environment
+			args = append(args, "-e", key)
+		}
+	}
+	if e.limits.Memory > 0 {
+		// without swap the memory limit is a hard limit, like with the local cgroups
+		memory := strconv.FormatUint(e.limits.Memory, 10)
+		args = append(args, "--memory", memory, "--memory-swap", memory)
+	}
+	if e.limits.CPU > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(e.limits.CPU, 'f', -1, 64))
+	}
+	if e.limits.NoFile > 0 {
+		args = append(args, "--ulimit", fmt.Sprintf("nofile=%d:%d", e.limits.NoFile, e.limits.NoFile))
+	}
+	return append(args, e.image, "sh", "-c", script)
+}
+
+func (e *Container) Run(job Job) Outcome {
+	name := fmt.Sprintf("paralix-%d-job-%d", os.Getpid(), job.Index)
+	outcome := runCommand(exec.Command(e.runtime, e.runArgs(name, job.Command)...), job)
+	// the container is kept until it is inspected, killing the runtime client on timeout doesn't stop it so rm -f does
+	if e.limits.Memory > 0 {
+		inspect, err := exec.Command(e.runtime, "inspect", "--format", "{{.State.OOMKilled}}", name).Output()
+		if err == nil && strings.TrimSpace(string(inspect)) == "true" {
+			outcome.LimitExceeded = "memory"
+			outcome.Err = fmt.Errorf("killed after exceeding the job memory limit of %d bytes", e.limits.Memory)
+		}
+	}
+	if err := exec.Command(e.runtime, "rm", "-f", name).Run(); err != nil {
+		logger.Log.Warnf("Failed to remove the container %s of job %d: %v", name, job.Index, err)
+	}
+	return outcome
+}
+
+func (e *Container) Close() error {
+	return nil
+}
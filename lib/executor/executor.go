@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+// Job is a rendered command for an executor to run
+type Job struct {
+	Index   int
+	Command string
+	// the host of the slot the job runs in, empty for the local machine
+	Host    string
+	Stdout  io.Writer
+	Timeout time.Duration
+	// closing Cancel kills the job
+	Cancel <-chan struct{}
+	// files copied next to a remote job before it runs, and paths fetched from its directory into ReturnDir after it finished
+	Transfers []Transfer
+	Returns   []string
+	ReturnDir string
+}
+
+type Transfer struct {
+	Local string
+	// relative to the directory the remote job runs in
+	Remote string
+}
+
+type Outcome struct {
+	// the host that ran the job, for executors that pick it themselves
+	Host     string
+	ExitCode int
+	Signal   int
+	TimedOut bool
+	// the job limit that got the job killed, like "memory"
+	LimitExceeded string
+	Err           error
+}
+
+// Executor runs the jobs of a run, Run is called concurrently from the job slots and Close once all the jobs finished
+type Executor interface {
+	Run(job Job) Outcome
+	Close() error
+}
+
+// Func is an in-process executor, mostly useful for tests
+type Func func(job Job) Outcome
+
+func (f Func) Run(job Job) Outcome {
+	return f(job)
+}
+
+func (f Func) Close() error {
+	return nil
+}
+
+type Options struct {
+	Limits           osutils.JobLimits
+	CgroupParent     string
+	SSHProgram       string
+	ContainerRuntime string
+	ContainerImage   string
+	// the directory mounted in the containers, the working directory when empty
+	Workspace string
+	// the address the coordinator listens on for workers, and how long a worker keeps a job without a heartbeat
+	ListenAddress string
+	LeaseDuration time.Duration
+	// the shared secret the workers send to the coordinator, no check when empty
+	Token string
+}
+
+type Factory func(options Options) (Executor, error)
+
+var registryMutex sync.Mutex
+var registry = map[string]Factory{}
+
+func init() {
+	Register("local", func(options Options) (Executor, error) {
+		return NewLocal(options.Limits, options.CgroupParent), nil
+	})
+	Register("ssh", func(options Options) (Executor, error) {
+		return NewSSH(options.SSHProgram)
+	})
+	Register("container", func(options Options) (Executor, error) {
+		return NewContainer(options.ContainerRuntime, options.ContainerImage, options.Workspace, options.Limits)
+	})
+}
+
+// Register makes an executor available by name, registering an existing name replaces it
+func Register(name string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[name] = factory
+}
+
+// Unregister removes an executor registered by name, like the in-process executors of tests
+func Unregister(name string) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	delete(registry, name)
+}
+
+func New(name string, options Options) (Executor, error) {
+	registryMutex.Lock()
+	factory, ok := registry[name]
+	registryMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown executor %q, available executors are %v", name, Names())
+	}
+	return factory(options)
+}
+
+func Names() []string {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runCommand(cmd *exec.Cmd, job Job) Outcome {
+	cmd.Stdout = job.Stdout
+	var outcome Outcome
+	outcome.TimedOut, outcome.Err = paralixutils.RunCmdWithTimeoutAndCancel(cmd, job.Timeout, job.Cancel)
+	outcome.ExitCode = paralixutils.GetExitCode(cmd, outcome.Err)
+	outcome.Signal = paralixutils.GetSignal(cmd)
+	return outcome
+}
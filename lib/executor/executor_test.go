@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+func TestRegistry(t *testing.T) {
+	Register("test", func(options Options) (Executor, error) {
+		return Func(func(job Job) Outcome {
+			job.Stdout.Write([]byte(job.Command))
+			return Outcome{ExitCode: job.Index}
+		}), nil
+	})
+	if !paralixutils.IsStringInSlice(Names(), "test") || !paralixutils.IsStringInSlice(Names(), "local") {
+		t.Errorf("Names() = %v, want the registered and the built-in executors", Names())
+	}
+	testExecutor, err := New("test", Options{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var stdout bytes.Buffer
+	outcome := testExecutor.Run(Job{Index: 7, Command: "in process", Stdout: &stdout})
+	if outcome.ExitCode != 7 || stdout.String() != "in process" {
+		t.Errorf("Run() = %v with stdout %q", outcome, stdout.String())
+	}
+	if _, err := New("missing", Options{}); err == nil {
+		t.Errorf("New() of an unknown executor should fail")
+	}
+	Unregister("test")
+	if paralixutils.IsStringInSlice(Names(), "test") {
+		t.Errorf("Names() = %v after Unregister(), want no test executor", Names())
+	}
+}
+
+func TestLocal(t *testing.T) {
+	tests := []struct {
+		name       string
+		job        Job
+		wantStdout string
+		want       Outcome
+		wantErr    bool
+	}{
+		{name: "success", job: Job{Command: "echo hello"}, wantStdout: "hello\n", want: Outcome{}},
+		{name: "failure", job: Job{Command: "echo failing; exit 4"}, wantStdout: "failing\n", want: Outcome{ExitCode: 4}, wantErr: true},
+		{name: "timeout", job: Job{Command: "sleep 30", Timeout: 100 * time.Millisecond}, want: Outcome{ExitCode: -1, Signal: 9, TimedOut: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			tt.job.Stdout = &stdout
+			got := NewLocal(osutils.JobLimits{}, "").Run(tt.job)
+			if (got.Err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", got.Err, tt.wantErr)
+			}
+			got.Err = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() = %+v, want %+v", got, tt.want)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("Run() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestLocalCancel(t *testing.T) {
+	cancel := make(chan struct{})
+	close(cancel)
+	got := NewLocal(osutils.JobLimits{}, "").Run(Job{Command: "sleep 30", Stdout: &bytes.Buffer{}, Cancel: cancel})
+	if !errors.Is(got.Err, paralixutils.ErrCanceled) {
+		t.Errorf("Run() error = %v, want %v", got.Err, paralixutils.ErrCanceled)
+	}
+}
+
+func TestSSH(t *testing.T) {
+	// the ssh stand-in runs the remote command locally
+	program := filepath.Join(t.TempDir(), "ssh")
+	ioutil.WriteFile(program, []byte("#!/bin/bash\n[ \"${@: -3:1}\" = \"-O\" ] && exit 0\nexport DESTINATION=\"${@: -2:1}\"\neval \"${@: -1}\"\n"), 0755)
+	sshExecutor, err := New("ssh", Options{SSHProgram: program})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer sshExecutor.Close()
+	var stdout bytes.Buffer
+	got := sshExecutor.Run(Job{Command: "echo on $DESTINATION; exit 2", Host: "user@h1", Stdout: &stdout})
+	if got.ExitCode != 2 || stdout.String() != "on user@h1\n" {
+		t.Errorf("Run() = %+v with stdout %q", got, stdout.String())
+	}
+	if got := sshExecutor.Run(Job{Command: "true", Stdout: &stdout}); got.Err == nil {
+		t.Errorf("Run() without a host should fail")
+	}
+}
+
+func TestContainer(t *testing.T) {
+	// the fake runtime logs its calls and runs the container command locally
+	binDir := t.TempDir()
+	callsLog := filepath.Join(binDir, "calls")
+	fakeRuntime := "#!/bin/bash\necho \"$*\" >> " + callsLog + "\ncase \"$1\" in\nrun) exec \"${@: -3:1}\" -c \"${@: -1}\";;\ninspect) echo false;;\nesac\n"
+	ioutil.WriteFile(filepath.Join(binDir, "podman"), []byte(fakeRuntime), 0755)
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+	t.Setenv("PARALIX_TEST_VALUE", "passed")
+	containerExecutor, err := New("container", Options{
+		ContainerRuntime: "podman",
+		ContainerImage:   "alpine:3.19",
+		Workspace:        "/work",
+		Limits:           osutils.JobLimits{Memory: 1024, CPU: 0.5},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var stdout bytes.Buffer
+	got := containerExecutor.Run(Job{Index: 3, Command: "echo $PARALIX_TEST_VALUE; exit 5", Stdout: &stdout})
+	if got.ExitCode != 5 || got.LimitExceeded != "" || stdout.String() != "passed\n" {
+		t.Errorf("Run() = %+v with stdout %q", got, stdout.String())
+	}
+	content, _ := ioutil.ReadFile(callsLog)
+	calls := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(calls) != 3 {
+		t.Fatalf("the runtime was called %d times, want run, inspect and rm: %q", len(calls), calls)
+	}
+	name := fmt.Sprintf("paralix-%d-job-3", os.Getpid())
+	for _, want := range []string{"run --name " + name + " -v /work:/work -w /work", "-e PARALIX_TEST_VALUE", "--memory 1024 --memory-swap 1024 --cpus 0.5 alpine:3.19 sh -c"} {
+		if !strings.Contains(calls[0], want) {
+			t.Errorf("run call %q doesn't contain %q", calls[0], want)
+		}
+	}
+	if strings.Contains(calls[0], "-e PATH ") {
+		t.Errorf("run call %q passes PATH through", calls[0])
+	}
+	if calls[2] != "rm -f "+name {
+		t.Errorf("the container was not removed, last call %q", calls[2])
+	}
+}
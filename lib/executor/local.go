@@ -0,0 +1,43 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+)
+
+// Local runs the jobs with bash on this machine, under the job limits when there are any
+type Local struct {
+	limits       osutils.JobLimits
+	cgroupParent string
+}
+
+func NewLocal(limits osutils.JobLimits, cgroupParent string) *Local {
+	return &Local{limits: limits, cgroupParent: cgroupParent}
+}
+
+func (e *Local) Run(job Job) Outcome {
+	if e.limits.IsEmpty() {
+		return runCommand(exec.Command("bash", "-c", job.Command), job)
+	}
+	limitedJob, err := osutils.NewLimitedJob(e.limits, e.cgroupParent, fmt.Sprintf("paralix-%d-job-%d", os.Getpid(), job.Index))
+	if err != nil {
+		return Outcome{ExitCode: -1, Err: fmt.Errorf("failed to apply the job limits: %w", err)}
+	}
+	outcome := runCommand(exec.Command("bash", limitedJob.BashArgs(job.Command)...), job)
+	if limitedJob.MemoryLimitExceeded() {
+		outcome.LimitExceeded = "memory"
+		outcome.Err = fmt.Errorf("killed after exceeding the job memory limit of %d bytes", e.limits.Memory)
+	}
+	if err := limitedJob.Cleanup(); err != nil {
+		logger.Log.Warnf("Failed to remove the cgroup of job %d: %v", job.Index, err)
+	}
+	return outcome
+}
+
+func (e *Local) Close() error {
+	return nil
+}
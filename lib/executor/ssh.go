@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+	sshutils "github.com/tamirdavid/paralix/lib/sshUtils"
+)
+
+// SSH runs every job on the host of its slot
+type SSH struct {
+	client *sshutils.Client
+}
+
+func NewSSH(program string) (*SSH, error) {
+	if program == "" {
+		program = "ssh"
+	}
+	client, err := sshutils.NewClient(program)
+	if err != nil {
+		return nil, err
+	}
+	return &SSH{client: client}, nil
+}
+
+func (e *SSH) Run(job Job) Outcome {
+	if job.Host == "" {
+		return Outcome{ExitCode: -1, Err: fmt.Errorf("job %d has no host to run on", job.Index)}
+	}
+	if len(job.Transfers) == 0 && len(job.Returns) == 0 {
+		return runCommand(e.client.Command(job.Host, job.Command), job)
+	}
+	// with files to move the job runs in its own directory, which is removed once the returns are fetched
+	jobDir := e.client.JobDir(job.Index)
+	defer func() {
+		if err := e.client.Remove(job.Host, jobDir); err != nil {
+			logger.Log.Warnf("Failed to remove %s:%s of job %d: %v", job.Host, jobDir, job.Index, err)
+		}
+	}()
+	for _, transfer := range job.Transfers {
+		if err := e.client.Upload(job.Host, transfer.Local, path.Join(jobDir, transfer.Remote)); err != nil {
+			return Outcome{ExitCode: -1, Err: err}
+		}
+	}
+	script := fmt.Sprintf("mkdir -p -- %s && cd %s || exit 255\n%s", sshutils.ShellQuote(jobDir), sshutils.ShellQuote(jobDir), job.Command)
+	outcome := runCommand(e.client.Command(job.Host, script), job)
+	if len(job.Returns) > 0 {
+		if err := e.client.Download(job.Host, jobDir, job.Returns, job.ReturnDir); err != nil {
+			logger.Log.Warnf("Job %d: %v", job.Index, err)
+			if outcome.Err == nil {
+				outcome.Err = err
+			}
+		}
+	}
+	return outcome
+}
+
+func (e *SSH) Close() error {
+	return e.client.Close()
+}
@@ -0,0 +1,76 @@
+package jobutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	HaltNever = "never"
+	// stop dispatching new jobs and wait for the running ones
+	HaltSoon = "soon"
+	// stop dispatching new jobs and kill the running ones
+	HaltNow = "now"
+)
+
+type HaltPolicy struct {
+	When      string
+	Condition string
+	Count     int
+	Percent   float64
+}
+
+func ParseHaltPolicy(policy string) (HaltPolicy, error) {
+	formatErr := fmt.Errorf("halt policy should be 'never' or in the format of when,condition=value (when: soon/now, condition: fail/success/done, value: a number or a percentage) but got %q", policy)
+	if policy == "" || policy == HaltNever {
+		return HaltPolicy{When: HaltNever}, nil
+	}
+	parts := strings.Split(policy, ",")
+	if len(parts) != 2 || (parts[0] != HaltSoon && parts[0] != HaltNow) {
+		return HaltPolicy{}, formatErr
+	}
+	condition := strings.SplitN(parts[1], "=", 2)
+	if len(condition) != 2 || (condition[0] != "fail" && condition[0] != "success" && condition[0] != "done") {
+		return HaltPolicy{}, formatErr
+	}
+	haltPolicy := HaltPolicy{When: parts[0], Condition: condition[0]}
+	if strings.HasSuffix(condition[1], "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(condition[1], "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return HaltPolicy{}, formatErr
+		}
+		haltPolicy.Percent = percent
+		return haltPolicy, nil
+	}
+	count, err := strconv.Atoi(condition[1])
+	if err != nil || count < 1 {
+		return HaltPolicy{}, formatErr
+	}
+	haltPolicy.Count = count
+	return haltPolicy, nil
+}
+
+func (h HaltPolicy) IsNever() bool {
+	return h.When == "" || h.When == HaltNever
+}
+
+func (h HaltPolicy) ShouldHalt(succeeded int, failed int, total int) bool {
+	if h.IsNever() {
+		return false
+	}
+	var matching int
+	switch h.Condition {
+	case "fail":
+		matching = failed
+	case "success":
+		matching = succeeded
+	case "done":
+		matching = succeeded + failed
+	}
+	if h.Percent > 0 {
+		// percentages are of all the jobs of the run
+		return total > 0 && float64(matching)*100/float64(total) >= h.Percent
+	}
+	return matching >= h.Count
+}
@@ -0,0 +1,72 @@
+package jobutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHaltPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		want    HaltPolicy
+		wantErr bool
+	}{
+		{name: "empty", policy: "", want: HaltPolicy{When: HaltNever}},
+		{name: "never", policy: "never", want: HaltPolicy{When: HaltNever}},
+		{name: "first failure", policy: "now,fail=1", want: HaltPolicy{When: HaltNow, Condition: "fail", Count: 1}},
+		{name: "failures soon", policy: "soon,fail=3", want: HaltPolicy{When: HaltSoon, Condition: "fail", Count: 3}},
+		{name: "failures percentage", policy: "now,fail=10%", want: HaltPolicy{When: HaltNow, Condition: "fail", Percent: 10}},
+		{name: "first success", policy: "now,success=1", want: HaltPolicy{When: HaltNow, Condition: "success", Count: 1}},
+		{name: "done", policy: "soon,done=5", want: HaltPolicy{When: HaltSoon, Condition: "done", Count: 5}},
+		{name: "unknown when", policy: "later,fail=1", wantErr: true},
+		{name: "unknown condition", policy: "now,error=1", wantErr: true},
+		{name: "missing value", policy: "now,fail", wantErr: true},
+		{name: "zero count", policy: "now,fail=0", wantErr: true},
+		{name: "percentage above 100", policy: "now,fail=120%", wantErr: true},
+		{name: "not a number", policy: "now,fail=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHaltPolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseHaltPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseHaltPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHaltPolicyShouldHalt(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		succeeded int
+		failed    int
+		total     int
+		want      bool
+	}{
+		{name: "never", policy: "never", failed: 10, total: 10, want: false},
+		{name: "below fail count", policy: "now,fail=2", failed: 1, total: 10, want: false},
+		{name: "fail count reached", policy: "now,fail=2", failed: 2, total: 10, want: true},
+		{name: "below fail percentage", policy: "soon,fail=25%", failed: 2, total: 10, want: false},
+		{name: "fail percentage reached", policy: "soon,fail=25%", failed: 3, total: 10, want: true},
+		{name: "success reached", policy: "now,success=1", succeeded: 1, failed: 4, total: 10, want: true},
+		{name: "failures don't count as success", policy: "now,success=1", failed: 4, total: 10, want: false},
+		{name: "done counts both", policy: "soon,done=5", succeeded: 2, failed: 3, total: 10, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseHaltPolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("ParseHaltPolicy() error = %v", err)
+			}
+			if got := policy.ShouldHalt(tt.succeeded, tt.failed, tt.total); got != tt.want {
+				t.Errorf("ShouldHalt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package jobutils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const jobLogHeader = "Seq\tHost\tSlot\tStarttime\tJobRuntime\tExitval\tSignal\tCommand"
+
+// the host of jobs that run on this machine, like in GNU parallel's joblog
+const LocalHost = ":"
+
+type JobLogEntry struct {
+	Seq      int
+	Host     string
+	Slot     int
+	Start    time.Time
+	Runtime  time.Duration
+	ExitCode int
+	Signal   int
+	Command  string
+}
+
+func NewJobLogEntry(result Result) JobLogEntry {
+	host := result.Host
+	if host == "" {
+		host = LocalHost
+	}
+	return JobLogEntry{
+		Seq:      result.Index,
+		Host:     host,
+		Slot:     result.Slot,
+		Start:    result.Start,
+		Runtime:  result.Duration,
+		ExitCode: result.ExitCode,
+		Signal:   result.Signal,
+		Command:  result.Command,
+	}
+}
+
+func (e JobLogEntry) String() string {
+	return fmt.Sprintf("%d\t%s\t%d\t%.3f\t%.3f\t%d\t%d\t%s",
+		e.Seq, e.Host, e.Slot, float64(e.Start.UnixNano())/float64(time.Second), e.Runtime.Seconds(),
+		e.ExitCode, e.Signal, escapeJobLogField(e.Command))
+}
+
+// JobLogDetails keeps what a job log line has no column for, next to the job output, so a resumed
+// run reports the jobs it takes over like the run that ran them
+type JobLogDetails struct {
+	Attempts      int    `json:"attempts"`
+	TimedOut      bool   `json:"timed_out,omitempty"`
+	LimitExceeded string `json:"limit_exceeded,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+func NewJobLogDetails(result Result) JobLogDetails {
+	details := JobLogDetails{
+		Attempts:      result.Attempts,
+		TimedOut:      result.TimedOut,
+		LimitExceeded: result.LimitExceeded,
+	}
+	if result.Err != nil {
+		details.Error = result.Err.Error()
+	}
+	return details
+}
+
+func WriteJobLogDetails(path string, details JobLogDetails) error {
+	content, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+func ReadJobLogDetails(path string) (JobLogDetails, error) {
+	var details JobLogDetails
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return details, err
+	}
+	err = json.Unmarshal(content, &details)
+	return details, err
+}
+
+// commands are escaped so every job stays on one tab separated line
+var jobLogEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func escapeJobLogField(field string) string {
+	return jobLogEscaper.Replace(field)
+}
+
+func unescapeJobLogField(field string) string {
+	var unescaped strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] != '\\' || i+1 == len(field) {
+			unescaped.WriteByte(field[i])
+			continue
+		}
+		i++
+		switch field[i] {
+		case 't':
+			unescaped.WriteByte('\t')
+		case 'n':
+			unescaped.WriteByte('\n')
+		case 'r':
+			unescaped.WriteByte('\r')
+		default:
+			unescaped.WriteByte(field[i])
+		}
+	}
+	return unescaped.String()
+}
+
+func ParseJobLogEntry(line string) (JobLogEntry, error) {
+	fields := strings.SplitN(line, "\t", 8)
+	if len(fields) != 8 {
+		return JobLogEntry{}, fmt.Errorf("expected 8 tab separated fields but got %d", len(fields))
+	}
+	seq, seqErr := strconv.Atoi(fields[0])
+	slot, slotErr := strconv.Atoi(fields[2])
+	start, startErr := strconv.ParseFloat(fields[3], 64)
+	runtime, runtimeErr := strconv.ParseFloat(fields[4], 64)
+	exitCode, exitCodeErr := strconv.Atoi(fields[5])
+	signal, signalErr := strconv.Atoi(fields[6])
+	for _, err := range []error{seqErr, slotErr, startErr, runtimeErr, exitCodeErr, signalErr} {
+		if err != nil {
+			return JobLogEntry{}, err
+		}
+	}
+	return JobLogEntry{
+		Seq:      seq,
+		Host:     fields[1],
+		Slot:     slot,
+		Start:    time.UnixMilli(int64(math.Round(start * 1000))),
+		Runtime:  time.Duration(math.Round(runtime*1000)) * time.Millisecond,
+		ExitCode: exitCode,
+		Signal:   signal,
+		Command:  unescapeJobLogField(fields[7]),
+	}, nil
+}
+
+func (e JobLogEntry) Succeeded() bool {
+	return e.ExitCode == 0 && e.Signal == 0
+}
+
+func ReadJobLog(path string) ([]JobLogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []JobLogEntry
+	var parseErr error
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if line == jobLogHeader || line == "" {
+			continue
+		}
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		entry, err := ParseJobLogEntry(line)
+		if err != nil {
+			// a crash can leave a partially written last line behind, only that line may be broken
+			parseErr = fmt.Errorf("%s line %d: %w", path, lineNumber, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+type JobLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func CreateJobLog(path string) (*JobLog, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	jobLog := &JobLog{file: file}
+	if err := jobLog.writeLine(jobLogHeader); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return jobLog, nil
+}
+
+func OpenJobLogForAppend(path string) (*JobLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	jobLog := &JobLog{file: file}
+	size, err := completeLinesSize(file)
+	if err == nil {
+		// the partial last line of a crashed run is dropped, the appended entries would continue it otherwise
+		err = file.Truncate(size)
+	}
+	if err == nil && size == 0 {
+		err = jobLog.writeLine(jobLogHeader)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return jobLog, nil
+}
+
+// completeLinesSize returns the size of the file up to the end of its last complete line
+func completeLinesSize(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	chunk := make([]byte, 4096)
+	for end := info.Size(); end > 0; {
+		start := end - int64(len(chunk))
+		if start < 0 {
+			start = 0
+		}
+		n, err := file.ReadAt(chunk[:end-start], start)
+		if err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(chunk[:n], '\n'); i != -1 {
+			return start + int64(i) + 1, nil
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+func (l *JobLog) Write(entry JobLogEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.writeLine(entry.String())
+}
+
+func (l *JobLog) writeLine(line string) error {
+	if _, err := fmt.Fprintln(l.file, line); err != nil {
+		return err
+	}
+	// every line is synced so the log survives a crash of paralix or of the machine
+	return l.file.Sync()
+}
+
+func (l *JobLog) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,203 @@
+package jobutils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobLogEntryString(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry JobLogEntry
+		want  string
+	}{
+		{
+			name: "simple command",
+			entry: NewJobLogEntry(Result{
+				Index:    3,
+				Slot:     2,
+				Command:  "echo a",
+				Start:    time.Unix(1700000000, 250000000),
+				Duration: 1500 * time.Millisecond,
+			}),
+			want: "3\t:\t2\t1700000000.250\t1.500\t0\t0\techo a",
+		},
+		{
+			name: "killed command with tabs and new lines",
+			entry: NewJobLogEntry(Result{
+				Index:    1,
+				Host:     "web1",
+				Slot:     1,
+				Command:  "printf 'a\\tb'\techo\nc",
+				Start:    time.Unix(1700000000, 0),
+				ExitCode: -1,
+				Signal:   9,
+			}),
+			want: "1\tweb1\t1\t1700000000.000\t0.000\t-1\t9\tprintf 'a\\\\tb'\\techo\\nc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.String(); got != tt.want {
+				t.Errorf("JobLogEntry.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "joblog")
+	jobLog, err := CreateJobLog(path)
+	if err != nil {
+		t.Fatalf("CreateJobLog() error = %v", err)
+	}
+	if err := jobLog.Write(NewJobLogEntry(Result{Index: 1, Slot: 1, Command: "echo a"})); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// lines are on disk before the log is closed
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read job log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 || lines[0] != jobLogHeader || !strings.HasSuffix(lines[1], "\techo a") {
+		t.Errorf("job log content = %q", content)
+	}
+	if err := jobLog.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestParseJobLogEntry(t *testing.T) {
+	entry := JobLogEntry{
+		Seq:      7,
+		Host:     LocalHost,
+		Slot:     3,
+		Start:    time.Unix(1700000000, 250000000),
+		Runtime:  1500 * time.Millisecond,
+		ExitCode: 2,
+		Signal:   0,
+		Command:  "printf 'a\\tb\\\\'\techo\nc",
+	}
+	got, err := ParseJobLogEntry(entry.String())
+	if err != nil {
+		t.Fatalf("ParseJobLogEntry() error = %v", err)
+	}
+	if got.Seq != entry.Seq || got.Host != entry.Host || got.Slot != entry.Slot || got.ExitCode != entry.ExitCode ||
+		got.Command != entry.Command || got.Runtime != entry.Runtime || got.Start.UnixMilli() != entry.Start.UnixMilli() {
+		t.Errorf("ParseJobLogEntry() = %+v, want %+v", got, entry)
+	}
+	if got.Succeeded() {
+		t.Errorf("Succeeded() = true for exit code 2")
+	}
+	if _, err := ParseJobLogEntry("1\t:\t1"); err == nil {
+		t.Errorf("ParseJobLogEntry() should fail on a partial line")
+	}
+	if _, err := ParseJobLogEntry("x\t:\t1\t1.0\t1.0\t0\t0\techo"); err == nil {
+		t.Errorf("ParseJobLogEntry() should fail on a non numeric seq")
+	}
+}
+
+func TestReadJobLog(t *testing.T) {
+	valid := JobLogEntry{Seq: 1, Host: LocalHost, Slot: 1, Start: time.Unix(1700000000, 0), Command: "echo a"}.String()
+	tests := []struct {
+		name        string
+		content     string
+		wantEntries int
+		wantErr     bool
+	}{
+		{name: "header only", content: jobLogHeader + "\n", wantEntries: 0},
+		{name: "entries", content: jobLogHeader + "\n" + valid + "\n" + valid + "\n", wantEntries: 2},
+		{name: "partial last line is ignored", content: jobLogHeader + "\n" + valid + "\n2\t:\t1\t17000", wantEntries: 1},
+		{name: "broken line in the middle", content: jobLogHeader + "\n2\t:\t1\n" + valid + "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "joblog")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write job log: %v", err)
+			}
+			got, err := ReadJobLog(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadJobLog() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantEntries {
+				t.Errorf("ReadJobLog() returned %d entries, want %d", len(got), tt.wantEntries)
+			}
+		})
+	}
+}
+
+func TestOpenJobLogForAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "joblog")
+	for i := 1; i <= 2; i++ {
+		jobLog, err := OpenJobLogForAppend(path)
+		if err != nil {
+			t.Fatalf("OpenJobLogForAppend() error = %v", err)
+		}
+		if err := jobLog.Write(NewJobLogEntry(Result{Index: i, Slot: 1, Command: "echo a"})); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		jobLog.Close()
+	}
+	entries, err := ReadJobLog(path)
+	if err != nil {
+		t.Fatalf("ReadJobLog() error = %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if len(entries) != 2 || strings.Count(string(content), jobLogHeader) != 1 {
+		t.Errorf("appended job log = %q", content)
+	}
+}
+
+func TestOpenJobLogForAppendAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "joblog")
+	jobLog, err := CreateJobLog(path)
+	if err != nil {
+		t.Fatalf("CreateJobLog() error = %v", err)
+	}
+	jobLog.Write(NewJobLogEntry(Result{Index: 1, Slot: 1, Command: "echo a"}))
+	// the crash leaves the line of job 2 half written
+	jobLog.file.WriteString("2\t:\t1\t17000")
+	jobLog.Close()
+	// resume, then resume again
+	for i := 2; i <= 3; i++ {
+		if _, err := ReadJobLog(path); err != nil {
+			t.Fatalf("ReadJobLog() before resume %d error = %v", i-1, err)
+		}
+		jobLog, err := OpenJobLogForAppend(path)
+		if err != nil {
+			t.Fatalf("OpenJobLogForAppend() error = %v", err)
+		}
+		jobLog.Write(NewJobLogEntry(Result{Index: i, Slot: 1, Command: "echo b"}))
+		jobLog.Close()
+	}
+	entries, err := ReadJobLog(path)
+	if err != nil {
+		t.Fatalf("ReadJobLog() error = %v", err)
+	}
+	if len(entries) != 3 || entries[1].Seq != 2 || entries[2].Seq != 3 {
+		t.Errorf("ReadJobLog() = %+v", entries)
+	}
+}
+
+func TestJobLogDetails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "000001.json")
+	result := Result{Attempts: 3, TimedOut: true, LimitExceeded: "memory", Err: errors.New("timed out after 1s")}
+	if err := WriteJobLogDetails(path, NewJobLogDetails(result)); err != nil {
+		t.Fatal(err)
+	}
+	details, err := ReadJobLogDetails(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := JobLogDetails{Attempts: 3, TimedOut: true, LimitExceeded: "memory", Error: "timed out after 1s"}
+	if details != want {
+		t.Errorf("ReadJobLogDetails() = %+v, want %+v", details, want)
+	}
+}
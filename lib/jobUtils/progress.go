@@ -0,0 +1,165 @@
+package jobutils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+)
+
+const (
+	terminalRefreshInterval = 200 * time.Millisecond
+	logRefreshInterval      = 10 * time.Second
+	progressBarWidth        = 30
+)
+
+type Progress struct {
+	mu            sync.Mutex
+	out           io.Writer
+	isTerminal    bool
+	total         int
+	running       int
+	done          int
+	failed        int
+	totalDuration time.Duration
+	start         time.Time
+	now           func() time.Time
+	stop          chan struct{}
+	stopped       chan struct{}
+}
+
+func NewProgress(out io.Writer, total int, isTerminal bool) *Progress {
+	return &Progress{out: out, total: total, isTerminal: isTerminal, now: time.Now}
+}
+
+func (p *Progress) Start() {
+	p.mu.Lock()
+	p.start = p.now()
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	p.mu.Unlock()
+
+	interval := logRefreshInterval
+	if p.isTerminal {
+		interval = terminalRefreshInterval
+	}
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.render()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (p *Progress) Stop() {
+	close(p.stop)
+	<-p.stopped
+	// leave the final state on screen
+	p.render()
+	if p.isTerminal {
+		p.mu.Lock()
+		fmt.Fprintln(p.out)
+		p.mu.Unlock()
+	}
+}
+
+func (p *Progress) JobStarted() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running++
+}
+
+func (p *Progress) JobFinished(duration time.Duration, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+	p.done++
+	p.totalDuration += duration
+	if failed {
+		p.failed++
+	}
+}
+
+func (p *Progress) render() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.isTerminal {
+		// rewrite the same line, \x1b[K clears what is left of a longer previous line
+		fmt.Fprintf(p.out, "\r%s\x1b[K", p.line())
+		return
+	}
+	logger.Log.Info(p.line())
+}
+
+func (p *Progress) Line() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.line()
+}
+
+func (p *Progress) line() string {
+	elapsed := p.now().Sub(p.start)
+	status := fmt.Sprintf("%d/%d done, %d running, %d failed | elapsed %s | ETA %s",
+		p.done, p.total, p.running, p.failed, formatDuration(elapsed), p.eta())
+	if !p.isTerminal {
+		return status
+	}
+	filled := 0
+	if p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+	return fmt.Sprintf("[%s%s] %s", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), status)
+}
+
+func (p *Progress) eta() string {
+	if p.done == 0 {
+		return "unknown"
+	}
+	remaining := p.total - p.done
+	if remaining <= 0 {
+		return formatDuration(0)
+	}
+	// the remaining jobs are expected to take the average job duration and to run as parallel as the current ones
+	parallelism := p.running
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	average := p.totalDuration / time.Duration(p.done)
+	return formatDuration(average * time.Duration(remaining) / time.Duration(parallelism))
+}
+
+func (p *Progress) LogWriter(w io.Writer) io.Writer {
+	return &progressLogWriter{progress: p, out: w}
+}
+
+// progressLogWriter clears the progress line before a log line is written and redraws it after,
+// so logs and the progress line don't get mixed on the terminal
+type progressLogWriter struct {
+	progress *Progress
+	out      io.Writer
+}
+
+func (w *progressLogWriter) Write(b []byte) (int, error) {
+	w.progress.mu.Lock()
+	defer w.progress.mu.Unlock()
+	fmt.Fprint(w.progress.out, "\r\x1b[K")
+	n, err := w.out.Write(b)
+	if !w.progress.start.IsZero() {
+		fmt.Fprint(w.progress.out, w.progress.line())
+	}
+	return n, err
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
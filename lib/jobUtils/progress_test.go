@@ -0,0 +1,81 @@
+package jobutils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestProgress(total int, isTerminal bool, elapsed time.Duration) *Progress {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewProgress(&bytes.Buffer{}, total, isTerminal)
+	p.start = start
+	p.now = func() time.Time { return start.Add(elapsed) }
+	return p
+}
+
+func TestProgressLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		isTerminal bool
+		started    int
+		finished   []time.Duration
+		failed     int
+		want       string
+	}{
+		{
+			name:    "nothing finished yet",
+			started: 2,
+			want:    "0/4 done, 2 running, 0 failed | elapsed 30s | ETA unknown",
+		},
+		{
+			name:     "eta from the average duration",
+			started:  4,
+			finished: []time.Duration{10 * time.Second, 30 * time.Second},
+			failed:   1,
+			want:     "2/4 done, 2 running, 1 failed | elapsed 30s | ETA 20s",
+		},
+		{
+			name:     "all done",
+			started:  4,
+			finished: []time.Duration{time.Second, time.Second, time.Second, time.Second},
+			want:     "4/4 done, 0 running, 0 failed | elapsed 30s | ETA 0s",
+		},
+		{
+			name:       "terminal bar",
+			isTerminal: true,
+			started:    2,
+			finished:   []time.Duration{time.Second, time.Second},
+			want:       "[" + strings.Repeat("=", 15) + strings.Repeat(" ", 15) + "] 2/4 done, 0 running, 0 failed | elapsed 30s | ETA 2s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProgress(4, tt.isTerminal, 30*time.Second)
+			for i := 0; i < tt.started; i++ {
+				p.JobStarted()
+			}
+			for i, duration := range tt.finished {
+				p.JobFinished(duration, i < tt.failed)
+			}
+			if got := p.Line(); got != tt.want {
+				t.Errorf("Line() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressLogWriter(t *testing.T) {
+	var out bytes.Buffer
+	p := newTestProgress(1, true, time.Second)
+	p.out = &out
+	w := p.LogWriter(&out)
+	if _, err := w.Write([]byte("a log line\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := "\r\x1b[Ka log line\n" + p.Line()
+	if out.String() != want {
+		t.Errorf("LogWriter() wrote %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,105 @@
+package jobutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var rateUnits = map[string]time.Duration{
+	"s":   time.Second,
+	"sec": time.Second,
+	"m":   time.Minute,
+	"min": time.Minute,
+	"h":   time.Hour,
+}
+
+func ParseRate(rate string) (float64, error) {
+	// rates are in the format of N/unit (10/s, 100/m, 1000/h), returned as starts per second
+	parts := strings.SplitN(rate, "/", 2)
+	formatErr := fmt.Errorf("rate should be in the format of N/s, N/m or N/h but got %q", rate)
+	if len(parts) != 2 {
+		return 0, formatErr
+	}
+	count, err := strconv.ParseFloat(parts[0], 64)
+	unit, knownUnit := rateUnits[parts[1]]
+	if err != nil || !knownUnit || count <= 0 {
+		return 0, formatErr
+	}
+	return count / unit.Seconds(), nil
+}
+
+// StartLimiter paces job starts with a token bucket of ratePerSecond (bursts up to burst starts)
+// and a minimal delay between two starts, a zero rate or delay disables that limit
+type StartLimiter struct {
+	mu            sync.Mutex
+	ratePerSecond float64
+	burst         float64
+	tokens        float64
+	delay         time.Duration
+	lastRefill    time.Time
+	lastStart     time.Time
+	now           func() time.Time
+	sleep         func(time.Duration, <-chan struct{})
+}
+
+func NewStartLimiter(ratePerSecond float64, burst int, delay time.Duration) *StartLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &StartLimiter{
+		ratePerSecond: ratePerSecond,
+		burst:         float64(burst),
+		tokens:        float64(burst),
+		delay:         delay,
+		now:           time.Now,
+		sleep:         sleepUnlessCanceled,
+	}
+}
+
+// Wait returns once the next job may start, or as soon as cancel is closed
+func (l *StartLimiter) Wait(cancel <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// starts are serialized, so waiting while holding the lock keeps them in order
+	l.sleep(l.reserve(), cancel)
+}
+
+func sleepUnlessCanceled(d time.Duration, cancel <-chan struct{}) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-cancel:
+	}
+}
+
+func (l *StartLimiter) reserve() time.Duration {
+	now := l.now()
+	var wait time.Duration
+	if l.ratePerSecond > 0 {
+		if !l.lastRefill.IsZero() {
+			l.tokens += now.Sub(l.lastRefill).Seconds() * l.ratePerSecond
+			if l.tokens > l.burst {
+				l.tokens = l.burst
+			}
+		}
+		l.lastRefill = now
+		if l.tokens < 1 {
+			wait = time.Duration((1 - l.tokens) / l.ratePerSecond * float64(time.Second))
+		}
+		l.tokens--
+	}
+	if l.delay > 0 && !l.lastStart.IsZero() {
+		if delayWait := l.lastStart.Add(l.delay).Sub(now); delayWait > wait {
+			wait = delayWait
+		}
+	}
+	l.lastStart = now.Add(wait)
+	return wait
+}
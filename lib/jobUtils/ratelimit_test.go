@@ -0,0 +1,105 @@
+package jobutils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    string
+		want    float64
+		wantErr bool
+	}{
+		{name: "per second", rate: "10/s", want: 10},
+		{name: "per minute", rate: "120/m", want: 2},
+		{name: "per hour", rate: "1800/h", want: 0.5},
+		{name: "fraction", rate: "0.5/s", want: 0.5},
+		{name: "missing unit", rate: "10", wantErr: true},
+		{name: "unknown unit", rate: "10/d", wantErr: true},
+		{name: "zero", rate: "0/s", wantErr: true},
+		{name: "not a number", rate: "x/s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRate(tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// startTimes returns the time of each start relative to the first call, with a fake clock
+func startTimes(limiter *StartLimiter, starts int) []time.Duration {
+	begin := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := begin
+	limiter.now = func() time.Time { return current }
+	limiter.sleep = func(d time.Duration, _ <-chan struct{}) { current = current.Add(d) }
+	var times []time.Duration
+	for i := 0; i < starts; i++ {
+		limiter.Wait(nil)
+		times = append(times, current.Sub(begin))
+	}
+	return times
+}
+
+func TestStartLimiter(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		limiter *StartLimiter
+		want    []time.Duration
+	}{
+		{
+			name:    "no limits",
+			limiter: NewStartLimiter(0, 0, 0),
+			want:    []time.Duration{0, 0, 0},
+		},
+		{
+			name:    "rate",
+			limiter: NewStartLimiter(10, 1, 0),
+			want:    []time.Duration{0, 100 * ms, 200 * ms, 300 * ms},
+		},
+		{
+			name:    "rate with burst",
+			limiter: NewStartLimiter(10, 3, 0),
+			want:    []time.Duration{0, 0, 0, 100 * ms, 200 * ms},
+		},
+		{
+			name:    "delay",
+			limiter: NewStartLimiter(0, 0, 200*ms),
+			want:    []time.Duration{0, 200 * ms, 400 * ms},
+		},
+		{
+			name:    "delay longer than the rate",
+			limiter: NewStartLimiter(10, 1, 250*ms),
+			want:    []time.Duration{0, 250 * ms, 500 * ms},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := startTimes(tt.limiter, len(tt.want)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("start times = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartLimiterCancel(t *testing.T) {
+	limiter := NewStartLimiter(0.01, 1, 0)
+	limiter.Wait(nil)
+	cancel := make(chan struct{})
+	close(cancel)
+	begin := time.Now()
+	limiter.Wait(cancel)
+	if waited := time.Since(begin); waited > time.Second {
+		t.Errorf("Wait() took %v after the cancel, want it to return at once", waited)
+	}
+}
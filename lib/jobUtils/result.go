@@ -0,0 +1,95 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type Result struct {
+	Index    int
+	Label    string
+	Values   map[string]string
+	Command  string
+	Host     string
+	Slot     int
+	Start    time.Time
+	Duration time.Duration
+	ExitCode int
+	Signal   int
+	TimedOut bool
+	// the number of times the job ran, more than 1 when it was retried
+	Attempts int
+	// the job limit that got the job killed, like "memory"
+	LimitExceeded string
+	Err           error
+	// set for jobs that never ran, explains why they were skipped
+	SkipReason string
+}
+
+func (r Result) Succeeded() bool {
+	return r.Err == nil && !r.Skipped()
+}
+
+func (r Result) Skipped() bool {
+	return r.SkipReason != ""
+}
+
+type ResultRecord struct {
+	Index           int               `json:"index"`
+	Values          map[string]string `json:"values"`
+	Command         string            `json:"command"`
+	Host            string            `json:"host,omitempty"`
+	Stdout          string            `json:"stdout"`
+	ExitCode        int               `json:"exit_code"`
+	TimedOut        bool              `json:"timed_out"`
+	Attempts        int               `json:"attempts,omitempty"`
+	LimitExceeded   string            `json:"limit_exceeded,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	Skipped         string            `json:"skipped,omitempty"`
+	Start           time.Time         `json:"start"`
+	DurationSeconds float64           `json:"duration_seconds"`
+}
+
+func NewResultRecord(result Result, stdout string) ResultRecord {
+	record := ResultRecord{
+		Index:           result.Index,
+		Values:          result.Values,
+		Command:         result.Command,
+		Host:            result.Host,
+		Stdout:          stdout,
+		ExitCode:        result.ExitCode,
+		TimedOut:        result.TimedOut,
+		Attempts:        result.Attempts,
+		LimitExceeded:   result.LimitExceeded,
+		Start:           result.Start,
+		DurationSeconds: result.Duration.Seconds(),
+		Skipped:         result.SkipReason,
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+	return record
+}
+
+func WriteResultsJSON(w io.Writer, records []ResultRecord, summary Summary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Results []ResultRecord `json:"results"`
+		Summary Summary        `json:"summary"`
+	}{Results: records, Summary: summary})
+}
+
+func WriteResultsJSONLines(w io.Writer, records []ResultRecord, summary Summary) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	// the summary is a trailer object, distinguishable from the records by its only key
+	return encoder.Encode(struct {
+		Summary Summary `json:"summary"`
+	}{Summary: summary})
+}
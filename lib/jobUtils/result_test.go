@@ -0,0 +1,65 @@
+package jobutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewResultRecord(t *testing.T) {
+	result := Result{
+		Index:    2,
+		Values:   map[string]string{"KEY": "b"},
+		Command:  "echo b; exit 1",
+		Duration: 1500 * time.Millisecond,
+		ExitCode: 1,
+		Err:      errors.New("exit status 1"),
+	}
+	record := NewResultRecord(result, "b\n")
+	if record.Index != 2 || record.Stdout != "b\n" || record.ExitCode != 1 || record.Error != "exit status 1" || record.DurationSeconds != 1.5 {
+		t.Errorf("NewResultRecord() = %+v", record)
+	}
+}
+
+func TestWriteResultsJSONLines(t *testing.T) {
+	records := []ResultRecord{
+		NewResultRecord(Result{Index: 1, Command: "echo a"}, "a\n"),
+		NewResultRecord(Result{Index: 2, Command: "echo b"}, "b\n"),
+	}
+	var out bytes.Buffer
+	if err := WriteResultsJSONLines(&out, records, Summary{Total: 2, Succeeded: 2}); err != nil {
+		t.Fatalf("WriteResultsJSONLines() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("WriteResultsJSONLines() wrote %d lines, want 3", len(lines))
+	}
+	var trailer map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[2]), &trailer); err != nil {
+		t.Fatalf("trailer is not valid json: %v", err)
+	}
+	if trailer["summary"]["total"] != 2.0 {
+		t.Errorf("trailer = %s", lines[2])
+	}
+}
+
+func TestWriteResultsJSON(t *testing.T) {
+	records := []ResultRecord{NewResultRecord(Result{Index: 1, Command: "echo a"}, "a\n")}
+	var out bytes.Buffer
+	if err := WriteResultsJSON(&out, records, Summary{Total: 1, Succeeded: 1}); err != nil {
+		t.Fatalf("WriteResultsJSON() error = %v", err)
+	}
+	var decoded struct {
+		Results []ResultRecord         `json:"results"`
+		Summary map[string]interface{} `json:"summary"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteResultsJSON() wrote invalid json: %v", err)
+	}
+	if len(decoded.Results) != 1 || decoded.Results[0].Stdout != "a\n" || decoded.Summary["total"] != 1.0 {
+		t.Errorf("WriteResultsJSON() = %s", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+// Selector picks values out of the result records of a previous run, like .stdout | lines
+type Selector struct {
+	field string
+	// the placeholder of .values.KEY
+	key     string
+	filters []string
+}
+
+var selectorFields = []string{"stdout", "command", "host", "error", "values.KEY"}
+
+func ParseSelector(expression string) (Selector, error) {
+	parts := strings.Split(expression, "|")
+	field := strings.TrimSpace(parts[0])
+	selector := Selector{field: strings.TrimPrefix(field, ".")}
+	if strings.HasPrefix(selector.field, "values.") {
+		selector.key = strings.TrimPrefix(selector.field, "values.")
+		selector.field = "values"
+	}
+	switch {
+	case !strings.HasPrefix(field, "."):
+		return Selector{}, fmt.Errorf("select %q should start with a field like .stdout", expression)
+	case selector.field == "values" && selector.key == "":
+		return Selector{}, fmt.Errorf("select %q should name the placeholder, like .values.KEY", expression)
+	case selector.field != "values" && !paralixutils.IsStringInSlice(selectorFields, selector.field):
+		return Selector{}, fmt.Errorf("select field %s should be one of .%s", field, strings.Join(selectorFields, ", ."))
+	}
+	for _, filter := range parts[1:] {
+		filter = strings.TrimSpace(filter)
+		if filter != "lines" && filter != "trim" {
+			return Selector{}, fmt.Errorf("select filter %q should be lines or trim", filter)
+		}
+		selector.filters = append(selector.filters, filter)
+	}
+	return selector, nil
+}
+
+// Select returns the values a record holds, the empty values are dropped
+func (s Selector) Select(record ResultRecord) []string {
+	var value string
+	switch s.field {
+	case "stdout":
+		value = record.Stdout
+	case "command":
+		value = record.Command
+	case "host":
+		value = record.Host
+	case "error":
+		value = record.Error
+	case "values":
+		value = record.Values[s.key]
+	}
+	values := []string{value}
+	for _, filter := range s.filters {
+		var filtered []string
+		for _, v := range values {
+			if filter == "lines" {
+				filtered = append(filtered, strings.Split(strings.ReplaceAll(v, "\r\n", "\n"), "\n")...)
+			} else {
+				filtered = append(filtered, strings.TrimSpace(v))
+			}
+		}
+		values = filtered
+	}
+	var selected []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			selected = append(selected, v)
+		}
+	}
+	return selected
+}
+
+func (r ResultRecord) Succeeded() bool {
+	return r.Error == "" && r.Skipped == ""
+}
+
+// ReadResultRecords reads the results file of a run in the json or jsonl format
+func ReadResultRecords(path string) ([]ResultRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	var records []ResultRecord
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s is not a json or jsonl results file: %w", path, err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, fmt.Errorf("%s is not a json or jsonl results file: %w", path, err)
+		}
+		if results, found := fields["results"]; found {
+			// the json format holds every record in its results array
+			var all []ResultRecord
+			if err := json.Unmarshal(results, &all); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			records = append(records, all...)
+			continue
+		}
+		if _, found := fields["summary"]; found && len(fields) == 1 {
+			continue
+		}
+		var record ResultRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
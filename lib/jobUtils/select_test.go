@@ -0,0 +1,77 @@
+package jobutils
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	record := ResultRecord{Stdout: "a\r\n\n  b \nc\n", Command: "ls /srv", Values: map[string]string{"DIR": "/srv"}}
+	tests := []struct {
+		expression string
+		want       []string
+		wantErr    bool
+	}{
+		{expression: ".stdout | lines", want: []string{"a", "  b ", "c"}},
+		{expression: ".stdout | lines | trim", want: []string{"a", "b", "c"}},
+		{expression: ".stdout|trim", want: []string{"a\r\n\n  b \nc"}},
+		{expression: ".values.DIR", want: []string{"/srv"}},
+		{expression: ".values.OTHER"},
+		{expression: ".command", want: []string{"ls /srv"}},
+		{expression: "stdout", wantErr: true},
+		{expression: ".exit_code", wantErr: true},
+		{expression: ".values", wantErr: true},
+		{expression: ".stdout | words", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			selector, err := ParseSelector(tt.expression)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := selector.Select(record); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadResultRecords(t *testing.T) {
+	records := []ResultRecord{
+		NewResultRecord(Result{Index: 1, Command: "echo a"}, "a\n"),
+		NewResultRecord(Result{Index: 2, Command: "echo b", Err: errors.New("exit status 1")}, "b\n"),
+	}
+	summary := Summary{Total: 2, Succeeded: 1, Failed: 1}
+	for format, write := range map[string]func(*bytes.Buffer) error{
+		"json":  func(out *bytes.Buffer) error { return WriteResultsJSON(out, records, summary) },
+		"jsonl": func(out *bytes.Buffer) error { return WriteResultsJSONLines(out, records, summary) },
+	} {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := write(&out); err != nil {
+				t.Fatalf("failed to write results: %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "results."+format)
+			ioutil.WriteFile(path, out.Bytes(), 0644)
+			got, err := ReadResultRecords(path)
+			if err != nil {
+				t.Fatalf("ReadResultRecords() error = %v", err)
+			}
+			if len(got) != 2 || got[0].Stdout != "a\n" || !got[0].Succeeded() || got[1].Succeeded() {
+				t.Errorf("ReadResultRecords() = %+v", got)
+			}
+		})
+	}
+	path := filepath.Join(t.TempDir(), "results.txt")
+	ioutil.WriteFile(path, []byte("a\n\nb\n"), 0644)
+	if _, err := ReadResultRecords(path); err == nil {
+		t.Errorf("ReadResultRecords() should fail on a text results file")
+	}
+}
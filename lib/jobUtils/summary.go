@@ -0,0 +1,159 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+type SlowJob struct {
+	Label    string
+	Duration time.Duration
+}
+
+type Summary struct {
+	Total     int
+	Succeeded int
+	// timed out jobs are counted in Failed as well
+	Failed         int
+	TimedOut       int
+	Skipped        int
+	WallTime       time.Duration
+	JobTime        time.Duration
+	MinDuration    time.Duration
+	MedianDuration time.Duration
+	P95Duration    time.Duration
+	MaxDuration    time.Duration
+	Slowest        []SlowJob
+}
+
+func Summarize(results []Result, wallTime time.Duration, slowestCount int) Summary {
+	summary := Summary{Total: len(results), WallTime: wallTime}
+	durations := make([]time.Duration, 0, len(results))
+	var ranResults []Result
+	for _, result := range results {
+		if result.Skipped() {
+			summary.Skipped++
+			continue
+		}
+		ranResults = append(ranResults, result)
+		if result.Succeeded() {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+		if result.TimedOut {
+			summary.TimedOut++
+		}
+		summary.JobTime += result.Duration
+		durations = append(durations, result.Duration)
+	}
+	if len(durations) == 0 {
+		return summary
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	summary.MinDuration = durations[0]
+	summary.MaxDuration = durations[len(durations)-1]
+	summary.MedianDuration = median(durations)
+	summary.P95Duration = percentile(durations, 95)
+
+	slowest := ranResults
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].Duration > slowest[j].Duration })
+	for i := 0; i < slowestCount && i < len(slowest); i++ {
+		summary.Slowest = append(summary.Slowest, SlowJob{Label: slowest[i].Label, Duration: slowest[i].Duration})
+	}
+	return summary
+}
+
+func median(sorted []time.Duration) time.Duration {
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	// nearest rank percentile
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (s Summary) Parallelism() float64 {
+	if s.WallTime <= 0 {
+		return 0
+	}
+	return float64(s.JobTime) / float64(s.WallTime)
+}
+
+func (s Summary) WriteTable(w io.Writer) {
+	fmt.Fprintln(w, "Summary")
+	fmt.Fprintf(w, "  Jobs:       %d total, %d succeeded, %d failed, %d timed out", s.Total, s.Succeeded, s.Failed, s.TimedOut)
+	if s.Skipped > 0 {
+		fmt.Fprintf(w, ", %d skipped", s.Skipped)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Wall time:  %s\n", formatSummaryDuration(s.WallTime))
+	fmt.Fprintf(w, "  Job time:   %s (effective parallelism %.1f)\n", formatSummaryDuration(s.JobTime), s.Parallelism())
+	fmt.Fprintf(w, "  Durations:  min %s, median %s, p95 %s, max %s\n",
+		formatSummaryDuration(s.MinDuration), formatSummaryDuration(s.MedianDuration),
+		formatSummaryDuration(s.P95Duration), formatSummaryDuration(s.MaxDuration))
+	if len(s.Slowest) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "  Slowest:")
+	for _, slow := range s.Slowest {
+		fmt.Fprintf(w, "    %10s  %s\n", formatSummaryDuration(slow.Duration), slow.Label)
+	}
+}
+
+func formatSummaryDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+type slowJobJSON struct {
+	Label           string  `json:"label"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+func (s Summary) MarshalJSON() ([]byte, error) {
+	slowest := make([]slowJobJSON, 0, len(s.Slowest))
+	for _, slow := range s.Slowest {
+		slowest = append(slowest, slowJobJSON{Label: slow.Label, DurationSeconds: slow.Duration.Seconds()})
+	}
+	return json.Marshal(struct {
+		Total                 int           `json:"total"`
+		Succeeded             int           `json:"succeeded"`
+		Failed                int           `json:"failed"`
+		TimedOut              int           `json:"timed_out"`
+		Skipped               int           `json:"skipped"`
+		WallTimeSeconds       float64       `json:"wall_time_seconds"`
+		JobTimeSeconds        float64       `json:"job_time_seconds"`
+		Parallelism           float64       `json:"effective_parallelism"`
+		MinDurationSeconds    float64       `json:"min_duration_seconds"`
+		MedianDurationSeconds float64       `json:"median_duration_seconds"`
+		P95DurationSeconds    float64       `json:"p95_duration_seconds"`
+		MaxDurationSeconds    float64       `json:"max_duration_seconds"`
+		Slowest               []slowJobJSON `json:"slowest"`
+	}{
+		Total:                 s.Total,
+		Succeeded:             s.Succeeded,
+		Failed:                s.Failed,
+		TimedOut:              s.TimedOut,
+		Skipped:               s.Skipped,
+		WallTimeSeconds:       s.WallTime.Seconds(),
+		JobTimeSeconds:        s.JobTime.Seconds(),
+		Parallelism:           s.Parallelism(),
+		MinDurationSeconds:    s.MinDuration.Seconds(),
+		MedianDurationSeconds: s.MedianDuration.Seconds(),
+		P95DurationSeconds:    s.P95Duration.Seconds(),
+		MaxDurationSeconds:    s.MaxDuration.Seconds(),
+		Slowest:               slowest,
+	})
+}
@@ -0,0 +1,137 @@
+package jobutils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resultsWithDurations(seconds ...int) []Result {
+	var results []Result
+	for i, s := range seconds {
+		results = append(results, Result{Index: i + 1, Label: string(rune('a' + i)), Duration: time.Duration(s) * time.Second})
+	}
+	return results
+}
+
+func TestSummarize(t *testing.T) {
+	failed := resultsWithDurations(4, 1, 3, 2)
+	failed[1].Err = errors.New("exit status 1")
+	failed[2].Err = errors.New("command timed out after 3s")
+	failed[2].TimedOut = true
+
+	tests := []struct {
+		name         string
+		results      []Result
+		wallTime     time.Duration
+		slowestCount int
+		want         Summary
+	}{
+		{
+			name:     "no jobs",
+			wallTime: time.Second,
+			want:     Summary{WallTime: time.Second},
+		},
+		{
+			name:         "counts and durations",
+			results:      failed,
+			wallTime:     5 * time.Second,
+			slowestCount: 2,
+			want: Summary{
+				Total:          4,
+				Succeeded:      2,
+				Failed:         2,
+				TimedOut:       1,
+				WallTime:       5 * time.Second,
+				JobTime:        10 * time.Second,
+				MinDuration:    time.Second,
+				MedianDuration: 2500 * time.Millisecond,
+				P95Duration:    4 * time.Second,
+				MaxDuration:    4 * time.Second,
+				Slowest:        []SlowJob{{Label: "a", Duration: 4 * time.Second}, {Label: "c", Duration: 3 * time.Second}},
+			},
+		},
+		{
+			name:         "odd number of jobs and more slowest than jobs",
+			results:      resultsWithDurations(3, 1, 2),
+			wallTime:     3 * time.Second,
+			slowestCount: 5,
+			want: Summary{
+				Total:          3,
+				Succeeded:      3,
+				WallTime:       3 * time.Second,
+				JobTime:        6 * time.Second,
+				MinDuration:    time.Second,
+				MedianDuration: 2 * time.Second,
+				P95Duration:    3 * time.Second,
+				MaxDuration:    3 * time.Second,
+				Slowest: []SlowJob{
+					{Label: "a", Duration: 3 * time.Second},
+					{Label: "c", Duration: 2 * time.Second},
+					{Label: "b", Duration: time.Second},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.results, tt.wallTime, tt.slowestCount); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryP95(t *testing.T) {
+	var seconds []int
+	for i := 1; i <= 100; i++ {
+		seconds = append(seconds, i)
+	}
+	summary := Summarize(resultsWithDurations(seconds...), time.Minute, 0)
+	if summary.P95Duration != 95*time.Second {
+		t.Errorf("Summarize() p95 = %s, want 95s", summary.P95Duration)
+	}
+}
+
+func TestSummaryWriteTable(t *testing.T) {
+	summary := Summarize(resultsWithDurations(4, 2), 4*time.Second, 1)
+	var out strings.Builder
+	summary.WriteTable(&out)
+	for _, want := range []string{
+		"2 total, 2 succeeded, 0 failed, 0 timed out",
+		"Job time:   6s (effective parallelism 1.5)",
+		"min 2s, median 3s, p95 4s, max 4s",
+		"4s  a",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteTable() = %q, should contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestSummaryMarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(Summarize(resultsWithDurations(4, 2), 4*time.Second, 1))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if decoded["total"] != 2.0 || decoded["effective_parallelism"] != 1.5 || decoded["p95_duration_seconds"] != 4.0 {
+		t.Errorf("json.Marshal() = %s", encoded)
+	}
+}
+
+func TestSummarizeSkipped(t *testing.T) {
+	results := resultsWithDurations(2, 0)
+	results[1].SkipReason = "halted"
+	summary := Summarize(results, time.Second, 5)
+	if summary.Total != 2 || summary.Succeeded != 1 || summary.Failed != 0 || summary.Skipped != 1 ||
+		summary.MinDuration != 2*time.Second || len(summary.Slowest) != 1 {
+		t.Errorf("Summarize() = %+v", summary)
+	}
+}
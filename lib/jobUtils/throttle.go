@@ -0,0 +1,88 @@
+package jobutils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+	osutils "github.com/tamirdavid/paralix/lib/osUtils"
+)
+
+const (
+	loadAveragePath     = "/proc/loadavg"
+	memoryInfoPath      = "/proc/meminfo"
+	throttlePollingTime = time.Second
+)
+
+// ResourceThrottle holds job starts while the machine is too loaded or short on memory,
+// a zero limit disables that check
+type ResourceThrottle struct {
+	MaxLoad       float64
+	MinFreeMemory uint64
+	readLoad      func() (float64, error)
+	readMemory    func() (uint64, error)
+	sleep         func(time.Duration, <-chan struct{})
+	warned        bool
+}
+
+func NewResourceThrottle(maxLoad float64, minFreeMemory uint64) *ResourceThrottle {
+	return &ResourceThrottle{
+		MaxLoad:       maxLoad,
+		MinFreeMemory: minFreeMemory,
+		readLoad:      func() (float64, error) { return osutils.ReadLoadAverage(loadAveragePath) },
+		readMemory:    func() (uint64, error) { return osutils.ReadAvailableMemory(memoryInfoPath) },
+		sleep:         sleepUnlessCanceled,
+	}
+}
+
+// Wait returns once the resources are available, or as soon as cancel is closed
+func (t *ResourceThrottle) Wait(cancel <-chan struct{}) {
+	paused := false
+	for {
+		select {
+		case <-cancel:
+			return
+		default:
+		}
+		reason := t.pauseReason()
+		if reason == "" {
+			if paused {
+				logger.Log.Info("Resources are available again, resuming job starts")
+			}
+			return
+		}
+		if !paused {
+			logger.Log.Warnf("Pausing job starts: %s", reason)
+			paused = true
+		}
+		t.sleep(throttlePollingTime, cancel)
+	}
+}
+
+func (t *ResourceThrottle) pauseReason() string {
+	if t.MaxLoad > 0 {
+		load, err := t.readLoad()
+		if err != nil {
+			t.warnOnce(err)
+		} else if load > t.MaxLoad {
+			return fmt.Sprintf("load average %.2f is above %.2f", load, t.MaxLoad)
+		}
+	}
+	if t.MinFreeMemory > 0 {
+		available, err := t.readMemory()
+		if err != nil {
+			t.warnOnce(err)
+		} else if available < t.MinFreeMemory {
+			return fmt.Sprintf("available memory %dMB is below %dMB", available>>20, t.MinFreeMemory>>20)
+		}
+	}
+	return ""
+}
+
+func (t *ResourceThrottle) warnOnce(err error) {
+	// the limits can't be checked on machines without /proc, jobs are started anyway
+	if !t.warned {
+		logger.Log.Warnf("Can't check the machine resources, starting jobs without throttling: %v", err)
+		t.warned = true
+	}
+}
@@ -0,0 +1,69 @@
+package jobutils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResourceThrottleWait(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxLoad       float64
+		minFreeMemory uint64
+		loads         []float64
+		memory        []uint64
+		readErr       error
+		wantSleeps    int
+	}{
+		{name: "no limits", loads: []float64{100}, memory: []uint64{0}, wantSleeps: 0},
+		{name: "load below limit", maxLoad: 8, loads: []float64{2}, wantSleeps: 0},
+		{name: "load drops below limit", maxLoad: 8, loads: []float64{12, 9, 7.5}, wantSleeps: 2},
+		{name: "memory becomes available", minFreeMemory: 2 << 30, memory: []uint64{1 << 30, 3 << 30}, wantSleeps: 1},
+		{name: "both limits", maxLoad: 8, minFreeMemory: 2 << 30, loads: []float64{1, 1, 1}, memory: []uint64{1 << 30, 1 << 30, 3 << 30}, wantSleeps: 2},
+		{name: "unreadable resources don't block", maxLoad: 8, minFreeMemory: 2 << 30, readErr: errors.New("no /proc"), wantSleeps: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := NewResourceThrottle(tt.maxLoad, tt.minFreeMemory)
+			loadReads, memoryReads, sleeps := 0, 0, 0
+			throttle.readLoad = func() (float64, error) {
+				load := tt.loads[len(tt.loads)-1]
+				if loadReads < len(tt.loads) {
+					load = tt.loads[loadReads]
+				}
+				loadReads++
+				return load, tt.readErr
+			}
+			throttle.readMemory = func() (uint64, error) {
+				memory := tt.memory[len(tt.memory)-1]
+				if memoryReads < len(tt.memory) {
+					memory = tt.memory[memoryReads]
+				}
+				memoryReads++
+				return memory, tt.readErr
+			}
+			throttle.sleep = func(time.Duration, <-chan struct{}) { sleeps++ }
+			if tt.readErr != nil {
+				throttle.readLoad = func() (float64, error) { return 0, tt.readErr }
+				throttle.readMemory = func() (uint64, error) { return 0, tt.readErr }
+			}
+			throttle.Wait(nil)
+			if sleeps != tt.wantSleeps {
+				t.Errorf("Wait() slept %d times, want %d", sleeps, tt.wantSleeps)
+			}
+		})
+	}
+}
+
+func TestResourceThrottleCancel(t *testing.T) {
+	throttle := NewResourceThrottle(8, 0)
+	throttle.readLoad = func() (float64, error) { return 12, nil }
+	cancel := make(chan struct{})
+	time.AfterFunc(10*time.Millisecond, func() { close(cancel) })
+	begin := time.Now()
+	throttle.Wait(cancel)
+	if waited := time.Since(begin); waited >= throttlePollingTime {
+		t.Errorf("Wait() took %v after the cancel, want it to return at once", waited)
+	}
+}
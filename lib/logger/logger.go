@@ -3,14 +3,59 @@
 package logger
 
 import (
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/sirupsen/logrus"
 )
 
+const timestampFormat = "2006-01-02 15:04:05"
+
 var Log = func() *logrus.Logger {
 	log := logrus.New()
 	log.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp:   true,
-		TimestampFormat: "2006-01-02 15:04:05",
+		TimestampFormat: timestampFormat,
 	})
 	return log
 }()
+
+// SetLogger makes the paralix packages log with the logger of the program using them
+func SetLogger(log *logrus.Logger) {
+	Log = log
+}
+
+// Options are the --log-level, --log-format and --log-file flags
+type Options struct {
+	Level  string
+	Format string
+	// appended to, stderr when empty
+	File string
+}
+
+// Configure applies the options to a logger, the returned closer closes the log file
+func Configure(log *logrus.Logger, options Options) (io.Closer, error) {
+	level, err := logrus.ParseLevel(options.Level)
+	if err != nil {
+		return nil, fmt.Errorf("log level should be one of panic, fatal, error, warn, info, debug or trace but got %q", options.Level)
+	}
+	switch options.Format {
+	case "text":
+		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true, TimestampFormat: timestampFormat, DisableColors: options.File != ""})
+	case "json":
+		log.SetFormatter(&logrus.JSONFormatter{TimestampFormat: timestampFormat})
+	default:
+		return nil, fmt.Errorf("log format should be text or json but got %q", options.Format)
+	}
+	log.SetLevel(level)
+	if options.File == "" {
+		return io.NopCloser(nil), nil
+	}
+	file, err := os.OpenFile(options.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	log.SetOutput(file)
+	return file, nil
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{name: "text", options: Options{Level: "info", Format: "text"}},
+		{name: "json", options: Options{Level: "debug", Format: "json"}},
+		{name: "unknown level", options: Options{Level: "verbose", Format: "text"}, wantErr: true},
+		{name: "unknown format", options: Options{Level: "info", Format: "xml"}, wantErr: true},
+		{name: "unwritable file", options: Options{Level: "info", Format: "text", File: filepath.Join(t.TempDir(), "missing", "paralix.log")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closer, err := Configure(logrus.New(), tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Configure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				closer.Close()
+			}
+		})
+	}
+}
+
+func TestConfigureJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paralix.log")
+	log := logrus.New()
+	closer, err := Configure(log, Options{Level: "warn", Format: "json", File: path})
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	log.WithFields(logrus.Fields{"job": 3, "exit_code": 1}).Warn("Job failed")
+	log.Info("not logged below the warn level")
+	closer.Close()
+	content, _ := ioutil.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("log file has %d lines, want 1:\n%s", len(lines), content)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log line is not json: %v", err)
+	}
+	if entry["job"] != 3.0 || entry["exit_code"] != 1.0 || entry["msg"] != "Job failed" || entry["level"] != "warning" {
+		t.Errorf("log entry = %v", entry)
+	}
+}
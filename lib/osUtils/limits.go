@@ -0,0 +1,267 @@
+package osUtils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// the period cpu.max quotas are relative to, in microseconds
+	cgroupCPUPeriod = 100000
+	// the removal of a job cgroup is retried up to about a second while its killed processes exit
+	cgroupRemoveAttempts = 10
+	cgroupRemoveDelay    = time.Millisecond
+)
+
+type JobLimits struct {
+	Memory uint64
+	CPU    float64
+	NoFile uint64
+}
+
+func (l JobLimits) IsEmpty() bool {
+	return l.Memory == 0 && l.CPU == 0 && l.NoFile == 0
+}
+
+// LimitedJob runs a bash script under the job limits, inside its own cgroup v2 when a cgroup
+// parent is given and with rlimits (ulimit) otherwise
+type LimitedJob struct {
+	limits    JobLimits
+	cgroupDir string
+}
+
+// CgroupParent is the cgroup the job cgroups are created in, Restore undoes what FindCgroupParent changed
+type CgroupParent struct {
+	Dir string
+	// the leaf cgroup paralix moved into and the controllers it enabled for the children, if any
+	leaf    string
+	enabled []string
+}
+
+// FindCgroupParent returns the cgroup paralix runs in as the parent of the job cgroups. A cgroup v2 with children
+// can't hold processes itself, so paralix first moves into a leaf cgroup of its own and enables the controllers
+// for the children, which only works when paralix is the only process of its cgroup
+func FindCgroupParent(procSelfCgroupPath string, cgroupRoot string, controllers []string) (*CgroupParent, error) {
+	// with cgroup v2 /proc/self/cgroup holds a single "0::/path" line
+	content, err := ioutil.ReadFile(procSelfCgroupPath)
+	if err != nil {
+		return nil, err
+	}
+	var cgroupPath string
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			cgroupPath = strings.TrimPrefix(line, "0::")
+		}
+	}
+	if cgroupPath == "" {
+		return nil, errors.New("cgroup v2 is not available")
+	}
+	parent := &CgroupParent{Dir: filepath.Join(cgroupRoot, cgroupPath)}
+	// the root cgroup is the only one allowed to hold both processes and children
+	if cgroupPath != "/" {
+		if parent.leaf, err = moveToLeafCgroup(parent.Dir); err != nil {
+			return nil, err
+		}
+		for _, controller := range controllers {
+			if CheckCgroupControllers(parent.Dir, []string{controller}) == nil {
+				continue
+			}
+			if err := writeCgroupFile(parent.Dir, "cgroup.subtree_control", "+"+controller); err != nil {
+				parent.Restore()
+				return nil, fmt.Errorf("failed to enable the %s controller in %s: %w", controller, parent.Dir, err)
+			}
+			parent.enabled = append(parent.enabled, controller)
+		}
+	}
+	if err := CheckCgroupControllers(parent.Dir, controllers); err != nil {
+		parent.Restore()
+		return nil, err
+	}
+	return parent, nil
+}
+
+// Restore disables the controllers paralix enabled, moves paralix back to its cgroup and removes its leaf cgroup,
+// once the job cgroups are removed
+func (p *CgroupParent) Restore() error {
+	var firstErr error
+	for _, controller := range p.enabled {
+		if err := writeCgroupFile(p.Dir, "cgroup.subtree_control", "-"+controller); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	p.enabled = nil
+	if p.leaf == "" {
+		return firstErr
+	}
+	if err := writeCgroupFile(p.Dir, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+		// paralix is still in the leaf, which can't be removed
+		if firstErr == nil {
+			firstErr = err
+		}
+		return firstErr
+	}
+	if err := os.Remove(p.leaf); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	p.leaf = ""
+	return firstErr
+}
+
+func moveToLeafCgroup(cgroupDir string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(cgroupDir, "cgroup.procs"))
+	if err != nil {
+		return "", err
+	}
+	pid := strconv.Itoa(os.Getpid())
+	for _, process := range strings.Fields(string(content)) {
+		if process != pid {
+			return "", fmt.Errorf("the cgroup %s of paralix holds other processes, job cgroups can't be created next to them", cgroupDir)
+		}
+	}
+	leaf := filepath.Join(cgroupDir, "paralix-"+pid)
+	if err := os.Mkdir(leaf, 0755); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", err
+	}
+	if err := writeCgroupFile(leaf, "cgroup.procs", pid); err != nil {
+		os.Remove(leaf)
+		return "", err
+	}
+	return leaf, nil
+}
+
+// writeCgroupFile writes to an existing cgroup interface file, the interface files can't be created
+func writeCgroupFile(cgroupDir string, name string, value string) error {
+	file, err := os.OpenFile(filepath.Join(cgroupDir, name), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(value); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func CheckCgroupControllers(cgroupDir string, controllers []string) error {
+	// the controllers must be enabled for the children of the cgroup
+	content, err := ioutil.ReadFile(filepath.Join(cgroupDir, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+	enabled := strings.Fields(string(content))
+	for _, controller := range controllers {
+		found := false
+		for _, e := range enabled {
+			if e == controller {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("the %s controller is not enabled in %s/cgroup.subtree_control", controller, cgroupDir)
+		}
+	}
+	return nil
+}
+
+func (l JobLimits) CgroupControllers() []string {
+	var controllers []string
+	if l.Memory > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if l.CPU > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	return controllers
+}
+
+func NewLimitedJob(limits JobLimits, cgroupParent string, name string) (*LimitedJob, error) {
+	job := &LimitedJob{limits: limits}
+	if cgroupParent == "" || len(limits.CgroupControllers()) == 0 {
+		return job, nil
+	}
+	cgroupDir := filepath.Join(cgroupParent, name)
+	if err := os.Mkdir(cgroupDir, 0755); err != nil {
+		return nil, err
+	}
+	job.cgroupDir = cgroupDir
+	if limits.Memory > 0 {
+		if err := ioutil.WriteFile(filepath.Join(cgroupDir, "memory.max"), []byte(strconv.FormatUint(limits.Memory, 10)), 0644); err != nil {
+			job.Cleanup()
+			return nil, err
+		}
+		// without swap the memory limit is a hard limit
+		ioutil.WriteFile(filepath.Join(cgroupDir, "memory.swap.max"), []byte("0"), 0644)
+	}
+	if limits.CPU > 0 {
+		quota := int(limits.CPU * cgroupCPUPeriod)
+		if err := ioutil.WriteFile(filepath.Join(cgroupDir, "cpu.max"), []byte(fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)), 0644); err != nil {
+			job.Cleanup()
+			return nil, err
+		}
+	}
+	return job, nil
+}
+
+func (j *LimitedJob) UsesCgroup() bool {
+	return j.cgroupDir != ""
+}
+
+func (j *LimitedJob) BashArgs(script string) []string {
+	// the wrapper joins the cgroup and sets the rlimits, then replaces itself with the job script
+	// which inherits both, the script and the cgroup are passed as arguments to avoid quoting issues
+	var wrapper []string
+	if j.cgroupDir != "" {
+		wrapper = append(wrapper, `echo $$ > "$1/cgroup.procs" || exit 125`)
+	} else if j.limits.Memory > 0 {
+		wrapper = append(wrapper, fmt.Sprintf("ulimit -v %d || exit 125", j.limits.Memory/1024))
+	}
+	if j.limits.NoFile > 0 {
+		wrapper = append(wrapper, fmt.Sprintf("ulimit -n %d || exit 125", j.limits.NoFile))
+	}
+	wrapper = append(wrapper, `exec bash -c "$2"`)
+	return []string{"-c", strings.Join(wrapper, "; "), "paralix-job", j.cgroupDir, script}
+}
+
+func (j *LimitedJob) MemoryLimitExceeded() bool {
+	if j.cgroupDir == "" {
+		return false
+	}
+	content, err := ioutil.ReadFile(filepath.Join(j.cgroupDir, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" && fields[1] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *LimitedJob) Cleanup() error {
+	if j.cgroupDir == "" {
+		return nil
+	}
+	// a cgroup can only be removed once it has no processes left. cgroup.kill, missing before linux 5.14,
+	// kills them but they leave the cgroup asynchronously so the removal is retried while it's busy
+	if err := writeCgroupFile(j.cgroupDir, "cgroup.kill", "1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to kill the processes left in %s: %w", j.cgroupDir, err)
+	}
+	delay := cgroupRemoveDelay
+	for attempt := 1; ; attempt++ {
+		err := os.Remove(j.cgroupDir)
+		if err == nil || !errors.Is(err, syscall.EBUSY) || attempt == cgroupRemoveAttempts {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,135 @@
+package osUtils
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFindCgroupParent(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name           string
+		procCgroup     string
+		processes      string
+		subtreeControl string
+		controllers    []string
+		wantParent     string
+		wantErr        bool
+	}{
+		{name: "controllers enabled", procCgroup: "0::/user.slice/paralix\n", processes: pid + "\n", subtreeControl: "cpu memory pids\n", controllers: []string{"memory", "cpu"}, wantParent: "user.slice/paralix"},
+		{name: "controller missing", procCgroup: "0::/user.slice/paralix\n", processes: pid + "\n", subtreeControl: "cpu memory pids\n", controllers: []string{"io"}, wantErr: true},
+		{name: "other processes", procCgroup: "0::/user.slice/paralix\n", processes: "1\n" + pid + "\n", subtreeControl: "cpu memory pids\n", controllers: []string{"memory"}, wantErr: true},
+		{name: "root cgroup", procCgroup: "0::/\n", processes: "1\n" + pid + "\n", subtreeControl: "cpu memory\n", controllers: []string{"memory"}, wantParent: ""},
+		{name: "cgroup v1", procCgroup: "12:memory:/user.slice\n", controllers: []string{"memory"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			cgroupDir := filepath.Join(root, strings.TrimPrefix(strings.TrimSpace(tt.procCgroup), "0::"))
+			os.MkdirAll(cgroupDir, 0755)
+			ioutil.WriteFile(filepath.Join(cgroupDir, "cgroup.procs"), []byte(tt.processes), 0644)
+			ioutil.WriteFile(filepath.Join(cgroupDir, "cgroup.subtree_control"), []byte(tt.subtreeControl), 0644)
+			// the kernel creates the interface files of a new cgroup
+			os.Mkdir(filepath.Join(cgroupDir, "paralix-"+pid), 0755)
+			ioutil.WriteFile(filepath.Join(cgroupDir, "paralix-"+pid, "cgroup.procs"), nil, 0644)
+			procCgroupPath := filepath.Join(t.TempDir(), "cgroup")
+			ioutil.WriteFile(procCgroupPath, []byte(tt.procCgroup), 0644)
+			got, err := FindCgroupParent(procCgroupPath, root, tt.controllers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindCgroupParent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if want := filepath.Join(root, tt.wantParent); got.Dir != want {
+				t.Errorf("FindCgroupParent() = %v, want %v", got.Dir, want)
+			}
+			// paralix moves out of a non root cgroup, into a leaf of its own
+			leaf := filepath.Join(got.Dir, "paralix-"+pid)
+			leafProcesses, _ := ioutil.ReadFile(filepath.Join(leaf, "cgroup.procs"))
+			moved := string(leafProcesses) == pid
+			if moved != (tt.wantParent != "") {
+				t.Errorf("paralix moved to a leaf cgroup = %v, leaf cgroup.procs = %q", moved, leafProcesses)
+			}
+			if !moved {
+				return
+			}
+			// the kernel removes the interface files with the cgroup
+			os.Remove(filepath.Join(leaf, "cgroup.procs"))
+			if err := got.Restore(); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			processes, _ := ioutil.ReadFile(filepath.Join(got.Dir, "cgroup.procs"))
+			if _, err := os.Stat(leaf); !os.IsNotExist(err) || !strings.HasPrefix(string(processes), pid) {
+				t.Errorf("Restore() left the leaf cgroup or didn't move paralix back, cgroup.procs = %q", processes)
+			}
+		})
+	}
+}
+
+func TestNewLimitedJobWithCgroup(t *testing.T) {
+	parent := t.TempDir()
+	job, err := NewLimitedJob(JobLimits{Memory: 512 << 20, CPU: 0.5}, parent, "job-1")
+	if err != nil {
+		t.Fatalf("NewLimitedJob() error = %v", err)
+	}
+	if !job.UsesCgroup() {
+		t.Fatalf("UsesCgroup() = false")
+	}
+	memoryMax, _ := ioutil.ReadFile(filepath.Join(parent, "job-1", "memory.max"))
+	cpuMax, _ := ioutil.ReadFile(filepath.Join(parent, "job-1", "cpu.max"))
+	if string(memoryMax) != "536870912" || string(cpuMax) != "50000 100000" {
+		t.Errorf("memory.max = %q, cpu.max = %q", memoryMax, cpuMax)
+	}
+	if job.MemoryLimitExceeded() {
+		t.Errorf("MemoryLimitExceeded() = true without memory.events")
+	}
+	ioutil.WriteFile(filepath.Join(parent, "job-1", "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n"), 0644)
+	if !job.MemoryLimitExceeded() {
+		t.Errorf("MemoryLimitExceeded() = false with an oom_kill event")
+	}
+	args := job.BashArgs("echo hi")
+	if !reflect.DeepEqual(args[2:], []string{"paralix-job", filepath.Join(parent, "job-1"), "echo hi"}) {
+		t.Errorf("BashArgs() = %q", args)
+	}
+}
+
+func TestLimitedJobRlimits(t *testing.T) {
+	job, err := NewLimitedJob(JobLimits{Memory: 512 << 20, NoFile: 64}, "", "job-1")
+	if err != nil {
+		t.Fatalf("NewLimitedJob() error = %v", err)
+	}
+	if job.UsesCgroup() {
+		t.Fatalf("UsesCgroup() = true without a cgroup parent")
+	}
+	output, err := exec.Command("bash", job.BashArgs(`echo "$(ulimit -v) $(ulimit -n) 'quoted'"`)...).Output()
+	if err != nil {
+		t.Fatalf("failed to run limited job: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "524288 64 'quoted'" {
+		t.Errorf("limited job output = %q", got)
+	}
+}
+
+func TestLimitedJobCleanup(t *testing.T) {
+	parent := t.TempDir()
+	job := &LimitedJob{cgroupDir: filepath.Join(parent, "job-1")}
+	os.Mkdir(job.cgroupDir, 0755)
+	// without cgroup.kill, before linux 5.14, the cgroup is removed right away
+	if err := job.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if _, err := os.Stat(job.cgroupDir); !os.IsNotExist(err) {
+		t.Errorf("job cgroup still exists after Cleanup()")
+	}
+	os.MkdirAll(filepath.Join(job.cgroupDir, "cgroup.kill"), 0755)
+	if err := job.Cleanup(); err == nil {
+		t.Errorf("Cleanup() should fail when the processes can't be killed")
+	}
+}
@@ -1,10 +1,15 @@
 package osUtils
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/tamirdavid/paralix/lib/logger"
 )
@@ -61,3 +66,72 @@ func ReadFilesContentAndWriteToOneFile(output *os.File, files []string) error {
 	}
 	return nil
 }
+
+func WriteFilesContentWithHeadersToOneFile(output *os.File, headers []string, files []string) error {
+	if len(headers) != len(files) {
+		return fmt.Errorf("got %d headers for %d files", len(headers), len(files))
+	}
+	for i, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output, "%s\n%s\n", headers[i], content)
+	}
+	return nil
+}
+
+func AskForConfirmation(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	// anything but an explicit yes is a no
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func ReadLoadAverage(loadavgPath string) (float64, error) {
+	// the first field of /proc/loadavg is the 1 minute load average
+	content, err := ioutil.ReadFile(loadavgPath)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("%s is empty", loadavgPath)
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+func ReadAvailableMemory(meminfoPath string) (uint64, error) {
+	// MemAvailable in /proc/meminfo is in kB, the result is in bytes
+	content, err := ioutil.ReadFile(meminfoPath)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kilobytes, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return kilobytes * 1024, nil
+	}
+	return 0, fmt.Errorf("MemAvailable is missing in %s", meminfoPath)
+}
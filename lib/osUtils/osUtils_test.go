@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -183,3 +184,143 @@ func TestCreateFile(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteFilesContentWithHeadersToOneFile(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "000000"), filepath.Join(dir, "000001")}
+	if err := ioutil.WriteFile(files[0], []byte("output of a"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if err := ioutil.WriteFile(files[1], []byte("output of b"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	tests := []struct {
+		name       string
+		headers    []string
+		files      []string
+		wantOutput string
+		wantErr    bool
+	}{
+		{
+			name:       "headers in file order",
+			headers:    []string{"https://a/x?y=1", "b"},
+			files:      files,
+			wantOutput: "https://a/x?y=1\noutput of a\nb\noutput of b\n",
+			wantErr:    false,
+		},
+		{
+			name:    "headers and files mismatch",
+			headers: []string{"a"},
+			files:   files,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := createTempFile(t)
+			defer os.Remove(output.Name())
+			err := WriteFilesContentWithHeadersToOneFile(output, tt.headers, tt.files)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WriteFilesContentWithHeadersToOneFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			outputContent, _ := ioutil.ReadFile(output.Name())
+			if !tt.wantErr && string(outputContent) != tt.wantOutput {
+				t.Errorf("output file content = %q, want %q", string(outputContent), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestAskForConfirmation(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  string
+		want    bool
+		wantErr bool
+	}{
+		{name: "yes", answer: "y\n", want: true},
+		{name: "full yes with spaces", answer: "  YES \n", want: true},
+		{name: "no", answer: "n\n", want: false},
+		{name: "empty answer defaults to no", answer: "\n", want: false},
+		{name: "no newline before end of input", answer: "y", want: true},
+		{name: "closed input", answer: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := AskForConfirmation(strings.NewReader(tt.answer), &out, "Run 3 jobs?")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AskForConfirmation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("AskForConfirmation() = %v, want %v", got, tt.want)
+			}
+			if out.String() != "Run 3 jobs? [y/N]: " {
+				t.Errorf("AskForConfirmation() prompt = %q", out.String())
+			}
+		})
+	}
+}
+
+func TestReadLoadAverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    float64
+		wantErr bool
+	}{
+		{name: "loadavg", content: "8.52 4.10 2.01 3/1234 5678\n", want: 8.52},
+		{name: "empty file", content: "", wantErr: true},
+		{name: "not a number", content: "x 1 1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "loadavg")
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+			got, err := ReadLoadAverage(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadLoadAverage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ReadLoadAverage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadAvailableMemory(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    uint64
+		wantErr bool
+	}{
+		{
+			name:    "meminfo",
+			content: "MemTotal:       16318412 kB\nMemFree:         1202396 kB\nMemAvailable:    2097152 kB\n",
+			want:    2 * 1024 * 1024 * 1024,
+		},
+		{name: "missing MemAvailable", content: "MemTotal:       16318412 kB\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "meminfo")
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+			got, err := ReadAvailableMemory(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadAvailableMemory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ReadAvailableMemory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
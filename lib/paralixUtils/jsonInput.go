@@ -0,0 +1,97 @@
+package paralixutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func ReadJSONObjectsFromFile(filepath string) ([]map[string]interface{}, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	// keep numbers as written in the file instead of converting them to float64
+	decoder.UseNumber()
+	var items []interface{}
+	if err := decoder.Decode(&items); err != nil {
+		return nil, fmt.Errorf("%s should contain a JSON array of objects: %w", filepath, err)
+	}
+	objects := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: element %d is not a JSON object", filepath, i+1)
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func ReadJSONLinesFromFile(filepath string) ([]map[string]interface{}, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	var objects []map[string]interface{}
+	for {
+		var item interface{}
+		err := decoder.Decode(&item)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to parse object %d: %w", filepath, len(objects)+1, err)
+		}
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: entry %d is not a JSON object", filepath, len(objects)+1)
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func GetNestedFieldAsString(object map[string]interface{}, fieldPath string) (string, error) {
+	// walk the object following the dot separated path (meta.region -> object["meta"]["region"])
+	var current interface{} = object
+	parts := strings.Split(fieldPath, ".")
+	for i, part := range parts {
+		nested, ok := current.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("field %q is not an object", strings.Join(parts[:i], "."))
+		}
+		value, exists := nested[part]
+		if !exists {
+			return "", fmt.Errorf("field %q is missing", strings.Join(parts[:i+1], "."))
+		}
+		current = value
+	}
+	switch value := current.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case nil:
+		return "", nil
+	case bool:
+		return fmt.Sprint(value), nil
+	default:
+		// objects and arrays are passed to the command as compact JSON
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
@@ -0,0 +1,126 @@
+package paralixutils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestReadJSONObjectsFromFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantSize int
+		wantErr  bool
+	}{
+		{
+			name:     "array of objects",
+			content:  `[{"name": "a"}, {"name": "b", "meta": {"region": "eu"}}]`,
+			wantSize: 2,
+			wantErr:  false,
+		},
+		{
+			name:    "array with non object element",
+			content: `[{"name": "a"}, "b"]`,
+			wantErr: true,
+		},
+		{
+			name:    "not an array",
+			content: `{"name": "a"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadJSONObjectsFromFile(writeTestFile(t, "input.json", tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadJSONObjectsFromFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantSize {
+				t.Errorf("ReadJSONObjectsFromFile() returned %d objects, want %d", len(got), tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestReadJSONLinesFromFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantSize int
+		wantErr  bool
+	}{
+		{
+			name:     "one object per line",
+			content:  "{\"name\": \"a\"}\n\n{\"name\": \"b\"}\n",
+			wantSize: 2,
+			wantErr:  false,
+		},
+		{
+			name:    "broken line",
+			content: "{\"name\": \"a\"}\n{\"name\": \n",
+			wantErr: true,
+		},
+		{
+			name:    "line is not an object",
+			content: "{\"name\": \"a\"}\n[1, 2]\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadJSONLinesFromFile(writeTestFile(t, "input.jsonl", tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadJSONLinesFromFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantSize {
+				t.Errorf("ReadJSONLinesFromFile() returned %d objects, want %d", len(got), tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestGetNestedFieldAsString(t *testing.T) {
+	objects, err := ReadJSONObjectsFromFile(writeTestFile(t, "input.json",
+		`[{"name": "svc", "port": 8080, "enabled": true, "meta": {"region": "eu-west-1", "tags": ["a", "b"]}}]`))
+	if err != nil {
+		t.Fatalf("failed to read test objects: %v", err)
+	}
+	tests := []struct {
+		name      string
+		fieldPath string
+		want      string
+		wantErr   bool
+	}{
+		{name: "top level string", fieldPath: "name", want: "svc"},
+		{name: "number keeps its format", fieldPath: "port", want: "8080"},
+		{name: "boolean", fieldPath: "enabled", want: "true"},
+		{name: "nested field", fieldPath: "meta.region", want: "eu-west-1"},
+		{name: "array as json", fieldPath: "meta.tags", want: `["a","b"]`},
+		{name: "missing field", fieldPath: "meta.zone", wantErr: true},
+		{name: "field of a non object", fieldPath: "name.first", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetNestedFieldAsString(objects[0], tt.fieldPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetNestedFieldAsString() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetNestedFieldAsString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
-	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/tamirdavid/paralix/lib/logger"
@@ -38,12 +38,44 @@ func GetMatchedRegexOccurencesFromString(regex string, input string) []string {
 }
 
 func IsStringInSlice(s []string, target string) bool {
-	sort.Strings(s)
-	i := sort.SearchStrings(s, target)
-	return i < len(s) && s[i] == target
+	// the slice is often shared, like the placeholders of the command, so it's searched as is and never sorted
+	for _, str := range s {
+		if str == target {
+			return true
+		}
+	}
+	return false
+}
+
+// UniqueStrings returns the strings without their repetitions, in the order they first appear
+func UniqueStrings(strs []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, str := range strs {
+		if !seen[str] {
+			seen[str] = true
+			unique = append(unique, str)
+		}
+	}
+	return unique
+}
+
+// lines longer than bufio's default 64KB limit are common in generated input files
+const maxInputLineSize = 16 * 1024 * 1024
+
+type ReadLinesOptions struct {
+	SkipBlankLines bool
+	SkipComments   bool
+	CommentPrefix  string
+	TrimWhitespace bool
+	Dedupe         bool
 }
 
 func ReadLinesFromFileReturnSliceOfLines(filepath string) ([]string, error) {
+	return ReadLinesFromFileWithOptions(filepath, ReadLinesOptions{})
+}
+
+func ReadLinesFromFileWithOptions(filepath string, options ReadLinesOptions) ([]string, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		return nil, err
@@ -51,41 +83,79 @@ func ReadLinesFromFileReturnSliceOfLines(filepath string) ([]string, error) {
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxInputLineSize)
+	// ScanLines also drops the \r of CRLF line endings
 	scanner.Split(bufio.ScanLines)
 
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	commentPrefix := options.CommentPrefix
+	if commentPrefix == "" {
+		commentPrefix = "#"
 	}
-	return lines, nil
-}
-
-func GetValuesBetweenDelimiters(str string, openChar string, closeChar string, splitChar string) ([]string, error) {
-	openCharIndex := strings.Index(str, openChar)
-	closeCharIndex := strings.Index(str, closeChar)
-	if openCharIndex == -1 || closeCharIndex == -1 {
-		return nil, fmt.Errorf("values should be in the format of %sVALUE1%s%sVALUE2%s%sVALUE3%s", openChar, closeChar, splitChar, splitChar, splitChar, closeChar)
+	var lines []string
+	seen := make(map[string]bool)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if lineNumber == 1 {
+			// files saved by windows editors may start with a byte order mark
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		if options.TrimWhitespace {
+			line = strings.TrimSpace(line)
+		}
+		if options.SkipBlankLines && strings.TrimSpace(line) == "" {
+			continue
+		}
+		if options.SkipComments && strings.HasPrefix(strings.TrimSpace(line), commentPrefix) {
+			continue
+		}
+		if options.Dedupe {
+			if seen[line] {
+				continue
+			}
+			seen[line] = true
+		}
+		lines = append(lines, line)
 	}
-	// extract the values between the braces
-	valuesStr := str[openCharIndex+len(openChar) : closeCharIndex]
-	// split the values string into a slice
-	values := strings.Split(valuesStr, splitChar)
-	// trim any leading or trailing spaces from the values
-	for i, val := range values {
-		values[i] = strings.TrimSpace(val)
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath, err)
 	}
-	return values, nil
+	return lines, nil
 }
 
 func RunCmdAndWaitForItToFinish(cmd *exec.Cmd) error {
-	logger.Log.Info("Executing command:" + cmd.String())
+	logger.Log.Debug("Executing command:" + cmd.String())
 	if ExecutionErr := cmd.Start(); ExecutionErr != nil {
 		fmt.Fprintf(os.Stderr, "Error starting command: %v\n", ExecutionErr)
 		return ExecutionErr
 	}
 	if waitingErr := cmd.Wait(); waitingErr != nil {
-		logger.Log.Errorf("Error waiting for command to complete: %v\n", waitingErr)
+		logger.Log.Debugf("Error waiting for command to complete: %v\n", waitingErr)
 		return waitingErr
 	}
 	return nil
 }
+
+var byteSizeUnits = map[string]uint64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+func ParseByteSize(size string) (uint64, error) {
+	// sizes are in the format of 512, 512K, 512M, 2G (or 2GB, 2GiB), units are powers of 1024
+	normalized := strings.ToUpper(strings.TrimSpace(size))
+	normalized = strings.TrimSuffix(strings.TrimSuffix(normalized, "IB"), "B")
+	numberEnd := strings.IndexFunc(normalized, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if numberEnd == -1 {
+		numberEnd = len(normalized)
+	}
+	number, err := strconv.ParseFloat(normalized[:numberEnd], 64)
+	unit, knownUnit := byteSizeUnits[normalized[numberEnd:]]
+	if err != nil || !knownUnit || number < 0 {
+		return 0, fmt.Errorf("size should be in the format of 512K, 512M or 2G but got %q", size)
+	}
+	return uint64(number * float64(unit)), nil
+}
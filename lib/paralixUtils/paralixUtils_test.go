@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -41,55 +42,6 @@ func TestRunCmdAndWaitForItToFinish(t *testing.T) {
 	}
 }
 
-func TestGetValuesBetweenDelimiters(t *testing.T) {
-	type args struct {
-		str       string
-		openChar  string
-		closeChar string
-		splitChar string
-	}
-	tests := []struct {
-		name    string
-		args    args
-		want    []string
-		wantErr bool
-	}{
-		{
-			name: "simple test case",
-			args: args{
-				str:       "Hello [world,how]",
-				openChar:  "[",
-				closeChar: "]",
-				splitChar: ",",
-			},
-			want:    []string{"world", "how"},
-			wantErr: false,
-		},
-		{
-			name: "missing close delimiter",
-			args: args{
-				str:       "Hello [world",
-				openChar:  "[",
-				closeChar: "]",
-				splitChar: ",",
-			},
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetValuesBetweenDelimiters(tt.args.str, tt.args.openChar, tt.args.closeChar, tt.args.splitChar)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetValuesBetweenDelimiters() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetValuesBetweenDelimiters() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func TestReadLinesFromFileReturnSliceOfLines(t *testing.T) {
 	type args struct {
 		filepath string
@@ -185,6 +137,18 @@ func TestIsStringInSlice(t *testing.T) {
 			}
 		})
 	}
+	unsorted := []string{"cherry", "apple", "banana"}
+	IsStringInSlice(unsorted, "apple")
+	if !reflect.DeepEqual(unsorted, []string{"cherry", "apple", "banana"}) {
+		t.Errorf("IsStringInSlice() reordered the slice to %v", unsorted)
+	}
+}
+
+func TestUniqueStrings(t *testing.T) {
+	got := UniqueStrings([]string{"b", "a", "b", "c", "a"})
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueStrings() = %v, want %v", got, want)
+	}
 }
 
 func TestGetMatchedRegexOccurencesFromString(t *testing.T) {
@@ -214,3 +178,99 @@ func TestGetMatchedRegexOccurencesFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestReadLinesFromFileWithOptions(t *testing.T) {
+	longLine := strings.Repeat("x", 100*1024)
+	tests := []struct {
+		name    string
+		content string
+		options ReadLinesOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "no options keeps every line",
+			content: "a\n\n# comment\n a \na\n",
+			options: ReadLinesOptions{},
+			want:    []string{"a", "", "# comment", " a ", "a"},
+		},
+		{
+			name:    "crlf line endings and byte order mark",
+			content: "\uFEFFa\r\nb\r\n",
+			options: ReadLinesOptions{},
+			want:    []string{"a", "b"},
+		},
+		{
+			name:    "skip blank lines and comments",
+			content: "a\n\n   \n# comment\n  # indented comment\nb\n",
+			options: ReadLinesOptions{SkipBlankLines: true, SkipComments: true},
+			want:    []string{"a", "b"},
+		},
+		{
+			name:    "custom comment prefix",
+			content: "a\n// comment\n# not a comment\n",
+			options: ReadLinesOptions{SkipComments: true, CommentPrefix: "//"},
+			want:    []string{"a", "# not a comment"},
+		},
+		{
+			name:    "trim and dedupe",
+			content: " a\na \nb\n\ta\n",
+			options: ReadLinesOptions{TrimWhitespace: true, Dedupe: true},
+			want:    []string{"a", "b"},
+		},
+		{
+			name:    "lines longer than 64KB",
+			content: "a\n" + longLine + "\nb\n",
+			options: ReadLinesOptions{},
+			want:    []string{"a", longLine, "b"},
+		},
+		{
+			name:    "line longer than the buffer limit",
+			content: strings.Repeat("x", maxInputLineSize+1) + "\n",
+			options: ReadLinesOptions{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadLinesFromFileWithOptions(writeTestFile(t, "input.txt", tt.content), tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadLinesFromFileWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadLinesFromFileWithOptions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "bytes", size: "512", want: 512},
+		{name: "kilobytes", size: "4K", want: 4096},
+		{name: "megabytes", size: "512M", want: 512 * 1024 * 1024},
+		{name: "gigabytes with suffix", size: "2GB", want: 2 * 1024 * 1024 * 1024},
+		{name: "gibibytes lowercase", size: "2gib", want: 2 * 1024 * 1024 * 1024},
+		{name: "fraction", size: "1.5G", want: 1536 * 1024 * 1024},
+		{name: "unknown unit", size: "2X", wantErr: true},
+		{name: "empty", size: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseByteSize(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseByteSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package paralixutils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// PlaceholderPattern matches the <KEY> placeholders of a command. A placeholder holds no < or >,
+// so a redirect next to one, like sort < <FILE>, is not taken for a placeholder
+var PlaceholderPattern = regexp.MustCompile("<([^<>]*)>")
+
+// CommandPlaceholders returns the keys of the placeholders of a command, in order and with repetitions
+func CommandPlaceholders(command string) []string {
+	var keys []string
+	for _, match := range PlaceholderPattern.FindAllStringSubmatch(command, -1) {
+		keys = append(keys, match[1])
+	}
+	return keys
+}
+
+// RenderPlaceholders replaces the placeholders of a template by their values in a single pass, so a value
+// holding the <placeholder> of another key is used as is and the result never depends on the map order.
+// The placeholders without a value are left as they are
+func RenderPlaceholders(template string, values map[string]string) string {
+	return PlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		if value, found := values[placeholder[1:len(placeholder)-1]]; found {
+			return value
+		}
+		return placeholder
+	})
+}
+
+type PlaceholderGroup struct {
+	Key    string
+	Values []string
+}
+
+type PlaceholderSyntaxError struct {
+	Input   string
+	Column  int
+	Message string
+}
+
+func (e *PlaceholderSyntaxError) Error() string {
+	// point at the offending column under the input
+	return fmt.Sprintf("invalid placeholder at column %d: %s\n  %s\n  %s^", e.Column, e.Message, e.Input, strings.Repeat(" ", e.Column-1))
+}
+
+type placeholderParser struct {
+	input []rune
+	pos   int
+}
+
+func ParsePlaceholders(input string) ([]PlaceholderGroup, error) {
+	// parse 'KEY={VALUE1,VALUE2} OTHER_KEY={...}', values can be quoted ("a,b" or 'a,b'), contain
+	// backslash escapes (a\,b) and nested braces ({"a":1,"b":2}), unquoted values are trimmed. Like bash
+	// brace expansion, KEY={0..3} and nested sequences like KEY={{0..3},10} or KEY={v{1..3}} are expanded
+	p := &placeholderParser{input: []rune(input)}
+	var groups []PlaceholderGroup
+	seenKeys := make(map[string]bool)
+	p.skipSeparators()
+	if p.done() {
+		return nil, p.errorf(p.pos, "expected KEY={VALUE1,VALUE2}")
+	}
+	for !p.done() {
+		keyPos := p.pos
+		group, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		if seenKeys[group.Key] {
+			return nil, p.errorf(keyPos, "key %q is passed more than once", group.Key)
+		}
+		seenKeys[group.Key] = true
+		groups = append(groups, group)
+		if !p.done() && !p.isSeparator(p.peek()) {
+			return nil, p.errorf(p.pos, "expected whitespace or ',' between placeholders, found %q", p.peek())
+		}
+		p.skipSeparators()
+	}
+	return groups, nil
+}
+
+func (p *placeholderParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *placeholderParser) peek() rune {
+	return p.input[p.pos]
+}
+
+func (p *placeholderParser) isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || r == ','
+}
+
+func (p *placeholderParser) skipSeparators() {
+	for !p.done() && p.isSeparator(p.peek()) {
+		p.pos++
+	}
+}
+
+func (p *placeholderParser) errorf(pos int, format string, args ...interface{}) error {
+	return &PlaceholderSyntaxError{Input: string(p.input), Column: pos + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *placeholderParser) parseGroup() (PlaceholderGroup, error) {
+	keyStart := p.pos
+	for !p.done() && p.peek() != '=' && p.peek() != '{' && !p.isSeparator(p.peek()) {
+		p.pos++
+	}
+	key := string(p.input[keyStart:p.pos])
+	if key == "" {
+		return PlaceholderGroup{}, p.errorf(keyStart, "expected a key before '='")
+	}
+	if p.done() || p.peek() != '=' {
+		return PlaceholderGroup{}, p.errorf(p.pos, "expected '=' after key %q", key)
+	}
+	p.pos++
+	if p.done() || p.peek() != '{' {
+		return PlaceholderGroup{}, p.errorf(p.pos, "expected '{' after \"%s=\"", key)
+	}
+	openPos := p.pos
+	p.pos++
+	var values []string
+	bareValues := 0
+	for {
+		value, bare, err := p.parseValue(openPos)
+		if err != nil {
+			return PlaceholderGroup{}, err
+		}
+		values = append(values, value...)
+		if bare {
+			bareValues++
+		}
+		// parseValue stops on ',' or on the closing '}'
+		closing := p.peek()
+		p.pos++
+		if closing == '}' {
+			break
+		}
+	}
+	// like bash, a sequence expression is expanded when it is the whole {...}, in a list {0..3,10} it is a plain value
+	if len(values) == 1 && bareValues == 1 {
+		expanded, err := ExpandRange(values[0])
+		if err != nil {
+			return PlaceholderGroup{}, p.errorf(openPos, "%v", err)
+		}
+		values = expanded
+	}
+	return PlaceholderGroup{Key: key, Values: values}, nil
+}
+
+// parseValue returns the values of one comma separated value, several when it holds nested sequence
+// expressions like v{1..3}, and whether it is a bare value, neither quoted, escaped nor holding braces
+func (p *placeholderParser) parseValue(openPos int) ([]string, bool, error) {
+	// the value is made of literal parts between the expanded nested sequences
+	var parts []string
+	var expansions [][]string
+	var value []rune
+	// protectedLen is the length of the value up to its last quoted or escaped rune,
+	// whitespace before it is part of the value and must not be trimmed
+	protectedLen := 0
+	started := false
+	bare := true
+	for {
+		if p.done() {
+			return nil, false, p.errorf(openPos, "'{' is never closed")
+		}
+		r := p.peek()
+		switch {
+		case r == ',' || r == '}':
+			trimmed := strings.TrimRightFunc(string(value[protectedLen:]), unicode.IsSpace)
+			values := []string{string(value[:protectedLen]) + trimmed}
+			// every combination of the nested sequences values, the last one changes fastest
+			for i := len(expansions) - 1; i >= 0; i-- {
+				var combined []string
+				for _, expanded := range expansions[i] {
+					for _, suffix := range values {
+						combined = append(combined, parts[i]+expanded+suffix)
+					}
+				}
+				values = combined
+			}
+			return values, bare, nil
+		case unicode.IsSpace(r) && !started:
+			// leading whitespace of unquoted values is ignored
+			p.pos++
+		case r == '\\':
+			if p.pos+1 >= len(p.input) {
+				return nil, false, p.errorf(p.pos, "'\\' at the end of the input escapes nothing")
+			}
+			value = append(value, p.input[p.pos+1])
+			p.pos += 2
+			protectedLen = len(value)
+			started, bare = true, false
+		case r == '"' || r == '\'':
+			quoted, err := p.parseQuoted()
+			if err != nil {
+				return nil, false, err
+			}
+			value = append(value, quoted...)
+			protectedLen = len(value)
+			started, bare = true, false
+		case r == '{':
+			nestedPos := p.pos
+			nested, err := p.parseNestedBraces()
+			if err != nil {
+				return nil, false, err
+			}
+			started, bare = true, false
+			sequence := string(nested[1 : len(nested)-1])
+			if !IsRange(sequence) {
+				// other braces are kept as is, like the braces of JSON values
+				value = append(value, nested...)
+				protectedLen = len(value)
+				continue
+			}
+			expanded, err := ExpandRange(sequence)
+			if err != nil {
+				return nil, false, p.errorf(nestedPos, "%v", err)
+			}
+			parts = append(parts, string(value))
+			expansions = append(expansions, expanded)
+			value, protectedLen = nil, 0
+		default:
+			value = append(value, r)
+			p.pos++
+			started = true
+		}
+	}
+}
+
+func (p *placeholderParser) parseQuoted() ([]rune, error) {
+	quote := p.peek()
+	quotePos := p.pos
+	p.pos++
+	var value []rune
+	for !p.done() {
+		r := p.peek()
+		switch {
+		case r == quote:
+			p.pos++
+			return value, nil
+		case r == '\\' && quote == '"' && p.pos+1 < len(p.input):
+			// backslash escapes are only honored inside double quotes, like in the shell
+			value = append(value, p.input[p.pos+1])
+			p.pos += 2
+		default:
+			value = append(value, r)
+			p.pos++
+		}
+	}
+	return nil, p.errorf(quotePos, "quote %c is never closed", quote)
+}
+
+func (p *placeholderParser) parseNestedBraces() ([]rune, error) {
+	// nested braces are kept as is, including commas, quotes and escapes inside them
+	openPos := p.pos
+	start := p.pos
+	depth := 0
+	var quote rune
+	for !p.done() {
+		r := p.peek()
+		switch {
+		case r == '\\':
+			p.pos++
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '{':
+			depth++
+		case r == '}':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return p.input[start:p.pos], nil
+			}
+		}
+		p.pos++
+	}
+	return nil, p.errorf(openPos, "'{' is never closed")
+}
@@ -0,0 +1,134 @@
+package paralixutils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePlaceholders(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []PlaceholderGroup
+		wantErr bool
+	}{
+		{
+			name:  "single group",
+			input: "KEY={a, b ,c}",
+			want:  []PlaceholderGroup{{Key: "KEY", Values: []string{"a", "b", "c"}}},
+		},
+		{
+			name:  "multiple groups",
+			input: "A={1,2} B={x},C={0..2}",
+			want: []PlaceholderGroup{
+				{Key: "A", Values: []string{"1", "2"}},
+				{Key: "B", Values: []string{"x"}},
+				{Key: "C", Values: []string{"0", "1", "2"}},
+			},
+		},
+		{
+			name:  "quoted values",
+			input: `URL={"https://a.io/?x=1,y=2", ' spaced ', "say \"hi\""}`,
+			want:  []PlaceholderGroup{{Key: "URL", Values: []string{"https://a.io/?x=1,y=2", " spaced ", `say "hi"`}}},
+		},
+		{
+			name:  "quoted range is not expanded",
+			input: `R={"1..3"}`,
+			want:  []PlaceholderGroup{{Key: "R", Values: []string{"1..3"}}},
+		},
+		{
+			name:  "range in a list is a plain value like in bash",
+			input: "S={0..2,10}",
+			want:  []PlaceholderGroup{{Key: "S", Values: []string{"0..2", "10"}}},
+		},
+		{
+			name:  "nested ranges",
+			input: "S={{0..2},10} H={web{1..2}.{a..b}}",
+			want: []PlaceholderGroup{
+				{Key: "S", Values: []string{"0", "1", "2", "10"}},
+				{Key: "H", Values: []string{"web1.a", "web1.b", "web2.a", "web2.b"}},
+			},
+		},
+		{
+			name:  "backslash escapes",
+			input: `V={a\,b,c\}d,e\\}`,
+			want:  []PlaceholderGroup{{Key: "V", Values: []string{"a,b", "c}d", `e\`}}},
+		},
+		{
+			name:  "nested braces",
+			input: `J={{"a":1,"b":{"c":"}"}},{"d":2}}`,
+			want:  []PlaceholderGroup{{Key: "J", Values: []string{`{"a":1,"b":{"c":"}"}}`, `{"d":2}`}}},
+		},
+		{
+			name:  "empty value",
+			input: "E={a,,b}",
+			want:  []PlaceholderGroup{{Key: "E", Values: []string{"a", "", "b"}}},
+		},
+		{name: "empty input", input: "  ", wantErr: true},
+		{name: "missing equal sign", input: "KEY{a}", wantErr: true},
+		{name: "missing open brace", input: "KEY=a,b", wantErr: true},
+		{name: "missing close brace", input: "KEY={a,b", wantErr: true},
+		{name: "unterminated quote", input: `KEY={"a,b}`, wantErr: true},
+		{name: "unterminated nested brace", input: `KEY={{"a":1}`, wantErr: true},
+		{name: "duplicate key", input: "A={1} A={2}", wantErr: true},
+		{name: "garbage after group", input: "A={1}x", wantErr: true},
+		{name: "range too large", input: "A={1..100000000}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePlaceholders(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePlaceholders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaceholdersErrorColumn(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantColumn int
+	}{
+		{name: "missing open brace", input: "A={1} KEY=value", wantColumn: 11},
+		{name: "missing close brace", input: "A={1} B={2", wantColumn: 9},
+		{name: "unterminated quote", input: `A={'x}`, wantColumn: 4},
+		{name: "garbage after group", input: "A={1}x", wantColumn: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePlaceholders(tt.input)
+			var syntaxErr *PlaceholderSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("ParsePlaceholders() error = %v, want a PlaceholderSyntaxError", err)
+			}
+			if syntaxErr.Column != tt.wantColumn {
+				t.Errorf("ParsePlaceholders() error column = %d, want %d", syntaxErr.Column, tt.wantColumn)
+			}
+		})
+	}
+}
+
+func TestCommandPlaceholders(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "cp <SRC> <DST>/<SRC>", want: []string{"SRC", "DST", "SRC"}},
+		{command: "sort < <FILE> > <FILE>.sorted", want: []string{"FILE", "FILE"}},
+		{command: "echo <stage.out>", want: []string{"stage.out"}},
+		{command: "echo no placeholders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := CommandPlaceholders(tt.command); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CommandPlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package paralixutils
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/tamirdavid/paralix/lib/logger"
+)
+
+var ErrCanceled = errors.New("command was killed before it finished")
+
+func RunCmdWithTimeout(cmd *exec.Cmd, timeout time.Duration) (bool, error) {
+	return RunCmdWithTimeoutAndCancel(cmd, timeout, nil)
+}
+
+func RunCmdWithTimeoutAndCancel(cmd *exec.Cmd, timeout time.Duration, cancel <-chan struct{}) (bool, error) {
+	if timeout <= 0 && cancel == nil {
+		return false, RunCmdAndWaitForItToFinish(cmd)
+	}
+	// the command runs in its own process group so its children are killed with it on timeout
+	setProcessGroup(cmd)
+	logger.Log.Debug("Executing command:" + cmd.String())
+	if executionErr := cmd.Start(); executionErr != nil {
+		logger.Log.Errorf("Error starting command: %v\n", executionErr)
+		return false, executionErr
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	// a nil channel never fires, so without a timeout only the cancel channel can stop the command
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	select {
+	case waitingErr := <-done:
+		if waitingErr != nil {
+			logger.Log.Debugf("Error waiting for command to complete: %v\n", waitingErr)
+		}
+		return false, waitingErr
+	case <-timeoutCh:
+		killProcessGroup(cmd)
+		<-done
+		logger.Log.Errorf("Command timed out after %s: %s\n", timeout, cmd.String())
+		return true, fmt.Errorf("command timed out after %s", timeout)
+	case <-cancel:
+		killProcessGroup(cmd)
+		<-done
+		logger.Log.Warnf("Command was killed: %s\n", cmd.String())
+		return false, ErrCanceled
+	}
+}
+
+func GetExitCode(cmd *exec.Cmd, err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if cmd.ProcessState != nil {
+		return cmd.ProcessState.ExitCode()
+	}
+	// the command could not even start
+	return -1
+}
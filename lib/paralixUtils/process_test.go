@@ -0,0 +1,94 @@
+package paralixutils
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunCmdWithTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		cmd          *exec.Cmd
+		timeout      time.Duration
+		wantTimedOut bool
+		wantErr      bool
+		wantExitCode int
+		wantSignal   int
+	}{
+		{
+			name:         "no timeout",
+			cmd:          exec.Command("bash", "-c", "exit 0"),
+			timeout:      0,
+			wantExitCode: 0,
+		},
+		{
+			name:         "finishes before timeout",
+			cmd:          exec.Command("bash", "-c", "exit 3"),
+			timeout:      5 * time.Second,
+			wantErr:      true,
+			wantExitCode: 3,
+		},
+		{
+			name:         "times out with its children",
+			cmd:          exec.Command("bash", "-c", "sleep 30 & sleep 30; wait"),
+			timeout:      100 * time.Millisecond,
+			wantTimedOut: true,
+			wantErr:      true,
+			wantExitCode: -1,
+			wantSignal:   9,
+		},
+		{
+			name:         "command not found",
+			cmd:          exec.Command("/nonexistent/binary"),
+			timeout:      time.Second,
+			wantErr:      true,
+			wantExitCode: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			timedOut, err := RunCmdWithTimeout(tt.cmd, tt.timeout)
+			if timedOut != tt.wantTimedOut {
+				t.Errorf("RunCmdWithTimeout() timedOut = %v, want %v", timedOut, tt.wantTimedOut)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RunCmdWithTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := GetExitCode(tt.cmd, err); got != tt.wantExitCode {
+				t.Errorf("GetExitCode() = %v, want %v", got, tt.wantExitCode)
+			}
+			if got := GetSignal(tt.cmd); got != tt.wantSignal {
+				t.Errorf("GetSignal() = %v, want %v", got, tt.wantSignal)
+			}
+			if time.Since(start) > 10*time.Second {
+				t.Errorf("RunCmdWithTimeout() took %s", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestGetExitCodeWithoutProcess(t *testing.T) {
+	if got := GetExitCode(exec.Command("true"), errors.New("failed to create output file")); got != -1 {
+		t.Errorf("GetExitCode() = %v, want -1", got)
+	}
+}
+
+func TestRunCmdWithTimeoutAndCancel(t *testing.T) {
+	cancel := make(chan struct{})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(cancel)
+	}()
+	cmd := exec.Command("bash", "-c", "sleep 30")
+	start := time.Now()
+	timedOut, err := RunCmdWithTimeoutAndCancel(cmd, 0, cancel)
+	if timedOut || !errors.Is(err, ErrCanceled) {
+		t.Errorf("RunCmdWithTimeoutAndCancel() = %v, %v, want false, ErrCanceled", timedOut, err)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("RunCmdWithTimeoutAndCancel() took %s", time.Since(start))
+	}
+}
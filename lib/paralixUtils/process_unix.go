@@ -0,0 +1,34 @@
+//go:build !windows
+
+package paralixutils
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	// a negative pid signals the whole process group
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+func GetSignal(cmd *exec.Cmd) int {
+	if cmd.ProcessState == nil {
+		return 0
+	}
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0
+	}
+	return int(status.Signal())
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package paralixutils
+
+import "os/exec"
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	cmd.Process.Kill()
+}
+
+func GetSignal(cmd *exec.Cmd) int {
+	return 0
+}
@@ -0,0 +1,111 @@
+package paralixutils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var numericRangeRegex = regexp.MustCompile(`^(-?\d+)\.\.(-?\d+)(?:\.\.(-?\d+))?$`)
+var letterRangeRegex = regexp.MustCompile(`^([a-zA-Z])\.\.([a-zA-Z])(?:\.\.(-?\d+))?$`)
+
+// MaxRangeValues is the largest number of values a range expands to, more is surely a typo
+const MaxRangeValues = 1000000
+
+// IsRange tells if the value is a sequence expression ExpandRange expands
+func IsRange(value string) bool {
+	return numericRangeRegex.MatchString(value) || letterRangeRegex.MatchString(value)
+}
+
+func ExpandRange(value string) ([]string, error) {
+	// expand bash style sequence expressions (0..63, 001..128, 0..100..5, a..z), anything else is returned as is
+	if match := numericRangeRegex.FindStringSubmatch(value); match != nil {
+		return expandNumericRange(match[1], match[2], match[3])
+	}
+	if match := letterRangeRegex.FindStringSubmatch(value); match != nil {
+		return expandLetterRange(match[1][0], match[2][0], match[3])
+	}
+	return []string{value}, nil
+}
+
+func parseRangeStep(stepStr string) (int, error) {
+	if stepStr == "" {
+		return 1, nil
+	}
+	step, err := strconv.Atoi(stepStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid range step %q: %w", stepStr, err)
+	}
+	// like bash, the direction comes from the range ends and not from the step sign
+	if step < 0 {
+		step = -step
+	}
+	if step == 0 {
+		step = 1
+	}
+	return step, nil
+}
+
+func hasLeadingZero(number string) bool {
+	number = strings.TrimPrefix(number, "-")
+	return len(number) > 1 && number[0] == '0'
+}
+
+func expandNumericRange(startStr string, endStr string, stepStr string) ([]string, error) {
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range start %q: %w", startStr, err)
+	}
+	end, err := strconv.Atoi(endStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range end %q: %w", endStr, err)
+	}
+	step, err := parseRangeStep(stepStr)
+	if err != nil {
+		return nil, err
+	}
+	// zero padded ends pad every value to the width of the widest end
+	width := 0
+	if hasLeadingZero(startStr) || hasLeadingZero(endStr) {
+		width = len(startStr)
+		if len(endStr) > width {
+			width = len(endStr)
+		}
+	}
+	// the unsigned difference of the ends can't overflow
+	distance := uint64(end) - uint64(start)
+	if end < start {
+		distance = uint64(start) - uint64(end)
+	}
+	if distance/uint64(step) >= MaxRangeValues {
+		return nil, fmt.Errorf("range %s..%s expands to more than %d values", startStr, endStr, MaxRangeValues)
+	}
+	// the values are derived from their count, stepping past an end near the int limits would overflow
+	count := distance/uint64(step) + 1
+	values := make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		offset := i * uint64(step)
+		value := int(uint64(start) + offset)
+		if end < start {
+			value = int(uint64(start) - offset)
+		}
+		values = append(values, fmt.Sprintf("%0*d", width, value))
+	}
+	return values, nil
+}
+
+func expandLetterRange(start byte, end byte, stepStr string) ([]string, error) {
+	step, err := parseRangeStep(stepStr)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		step = -step
+	}
+	var values []string
+	for i := int(start); (step > 0 && i <= int(end)) || (step < 0 && i >= int(end)); i += step {
+		values = append(values, string(rune(i)))
+	}
+	return values, nil
+}
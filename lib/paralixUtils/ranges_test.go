@@ -0,0 +1,53 @@
+package paralixutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "plain value", value: "HELLO", want: []string{"HELLO"}},
+		{name: "numeric range", value: "0..3", want: []string{"0", "1", "2", "3"}},
+		{name: "decreasing range", value: "3..1", want: []string{"3", "2", "1"}},
+		{name: "negative range", value: "-1..1", want: []string{"-1", "0", "1"}},
+		{name: "zero padded range", value: "001..003", want: []string{"001", "002", "003"}},
+		{name: "zero padded to widest end", value: "08..100..46", want: []string{"008", "054", "100"}},
+		{name: "stepped range", value: "0..20..5", want: []string{"0", "5", "10", "15", "20"}},
+		{name: "step sign is ignored", value: "10..0..-5", want: []string{"10", "5", "0"}},
+		{name: "zero step is one", value: "1..3..0", want: []string{"1", "2", "3"}},
+		{name: "letter range", value: "a..e", want: []string{"a", "b", "c", "d", "e"}},
+		{name: "stepped letter range", value: "z..t..2", want: []string{"z", "x", "v", "t"}},
+		{name: "not a range", value: "1..b", want: []string{"1..b"}},
+		{name: "two dots in value", value: "v1..x", want: []string{"v1..x"}},
+		{name: "step out of bounds", value: "1..3..99999999999999999999", wantErr: true},
+		{name: "too many values", value: "1..100000000", wantErr: true},
+		{name: "range up to the max int", value: "9223372036854775806..9223372036854775807", want: []string{"9223372036854775806", "9223372036854775807"}},
+		{name: "range down to the min int", value: "-9223372036854775807..-9223372036854775808", want: []string{"-9223372036854775807", "-9223372036854775808"}},
+		{name: "step over the whole int range", value: "-9223372036854775808..9223372036854775807..-9223372036854775808", want: []string{"-9223372036854775808", "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandRange(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExpandRange() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandRangeLimit(t *testing.T) {
+	got, err := ExpandRange("1..2000000..2")
+	if err != nil || len(got) != MaxRangeValues {
+		t.Errorf("ExpandRange() returned %d values and error %v, want %d values", len(got), err, MaxRangeValues)
+	}
+}
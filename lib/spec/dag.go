@@ -0,0 +1,116 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Task is a job of a DAG spec, it starts once every task it needs succeeded
+type Task struct {
+	Name    string   `yaml:"name"`
+	Command string   `yaml:"command"`
+	Needs   []string `yaml:"needs"`
+}
+
+func (s *Spec) IsDAG() bool {
+	return len(s.Tasks) > 0
+}
+
+func (s *Spec) taskIndex(name string) int {
+	for i, task := range s.Tasks {
+		if task.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Spec) validateTasks() []string {
+	var problems []string
+	if strings.TrimSpace(s.Command) != "" || len(s.Inputs) > 0 {
+		problems = append(problems, "command and inputs are set by every task of a DAG, not at the top level")
+	}
+	names := make(map[string]bool)
+	for i, task := range s.Tasks {
+		if !stageNamePattern.MatchString(task.Name) {
+			problems = append(problems, fmt.Sprintf("tasks[%d]: name should be made of letters, digits, _ and - but got %q", i, task.Name))
+		} else if names[task.Name] {
+			problems = append(problems, fmt.Sprintf("tasks[%d]: name %s is used by more than one task", i, task.Name))
+		}
+		names[task.Name] = true
+		if strings.TrimSpace(task.Command) == "" {
+			problems = append(problems, fmt.Sprintf("tasks[%d]: command is required", i))
+		}
+		for _, need := range task.Needs {
+			if need == task.Name {
+				problems = append(problems, fmt.Sprintf("tasks[%d]: %s can't need itself", i, task.Name))
+			} else if s.taskIndex(need) == -1 {
+				problems = append(problems, fmt.Sprintf("tasks[%d]: needs unknown task %s", i, need))
+			}
+		}
+	}
+	if cycle := s.findCycle(); cycle != nil {
+		problems = append(problems, fmt.Sprintf("tasks %s form a cycle", strings.Join(cycle, " -> ")))
+	}
+	return problems
+}
+
+// findCycle returns the names of the tasks of a cycle of needs, the first task repeated at the end, or nil without cycles
+func (s *Spec) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(s.Tasks))
+	var path []int
+	var visit func(i int) []string
+	visit = func(i int) []string {
+		state[i] = visiting
+		path = append(path, i)
+		for _, need := range s.Tasks[i].Needs {
+			// self needs and unknown tasks are reported on their own
+			next := s.taskIndex(need)
+			if next == -1 || next == i {
+				continue
+			}
+			switch state[next] {
+			case visiting:
+				var cycle []string
+				for j := len(path) - 1; j >= 0; j-- {
+					cycle = append([]string{s.Tasks[path[j]].Name}, cycle...)
+					if path[j] == next {
+						break
+					}
+				}
+				return append(cycle, s.Tasks[next].Name)
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+	for i := range s.Tasks {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// TaskNeeds returns the positions of the tasks every task needs
+func (s *Spec) TaskNeeds() [][]int {
+	needs := make([][]int, len(s.Tasks))
+	for i, task := range s.Tasks {
+		for _, need := range task.Needs {
+			needs[i] = append(needs[i], s.taskIndex(need))
+		}
+	}
+	return needs
+}
@@ -0,0 +1,264 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+)
+
+// stdoutReference is the name of the upstream value holding the stdout of the upstream job
+const stdoutReference = "stdout"
+
+var stageNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Stage is a fan-out of a pipeline, its command can use the values of the earlier stages
+// as <stage.name> placeholders: stdout, the outputs of the stage and its placeholder values
+type Stage struct {
+	Name    string            `yaml:"name"`
+	Command string            `yaml:"command"`
+	Inputs  []Input           `yaml:"inputs"`
+	Outputs map[string]string `yaml:"outputs"`
+}
+
+// PipelineJob is a job of a stage, a stage using the values of an earlier stage runs once for every
+// job of that stage and only waits for that job
+type PipelineJob struct {
+	Stage   string
+	Command string
+	// the values of the job and the values inherited from its upstream jobs
+	Values map[string]string
+	// the keys of Values in label order, the inherited keys first
+	Keys []string
+	// positions of the jobs it waits for
+	Needs []int
+	// position of the upstream job of every earlier stage it descends from
+	Upstream map[string]int
+}
+
+// Reference is a <stage.name> placeholder
+type Reference struct {
+	Stage string
+	Name  string
+}
+
+func (r Reference) Placeholder() string {
+	return r.Stage + "." + r.Name
+}
+
+// References returns the <stage.name> placeholders of a command whose stage is a stage of the pipeline
+func (s *Spec) References(command string) []Reference {
+	var references []Reference
+	seen := make(map[string]bool)
+	for _, placeholder := range paralixutils.CommandPlaceholders(command) {
+		dot := strings.Index(placeholder, ".")
+		if dot == -1 || seen[placeholder] || s.stageIndex(placeholder[:dot]) == -1 {
+			continue
+		}
+		seen[placeholder] = true
+		references = append(references, Reference{Stage: placeholder[:dot], Name: placeholder[dot+1:]})
+	}
+	return references
+}
+
+func (s *Spec) stageIndex(name string) int {
+	for i, stage := range s.Stages {
+		if stage.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Spec) validateStages() []string {
+	var problems []string
+	if strings.TrimSpace(s.Command) != "" || len(s.Inputs) > 0 {
+		problems = append(problems, "command and inputs are set by every stage of a pipeline, not at the top level")
+	}
+	names := make(map[string]bool)
+	for i, stage := range s.Stages {
+		if !stageNamePattern.MatchString(stage.Name) {
+			problems = append(problems, fmt.Sprintf("stages[%d]: name should be made of letters, digits, _ and - but got %q", i, stage.Name))
+		} else if names[stage.Name] {
+			problems = append(problems, fmt.Sprintf("stages[%d]: name %s is used by more than one stage", i, stage.Name))
+		}
+		names[stage.Name] = true
+		if strings.TrimSpace(stage.Command) == "" {
+			problems = append(problems, fmt.Sprintf("stages[%d]: command is required", i))
+		}
+		commandPlaceholders := paralixutils.CommandPlaceholders(stage.Command)
+		keys := make(map[string]bool)
+		for j, input := range stage.Inputs {
+			if input.Key != "" && !paralixutils.IsStringInSlice(commandPlaceholders, input.Key) {
+				problems = append(problems, fmt.Sprintf("stages[%d].inputs[%d]: <%s> is missing in the command", i, j, input.Key))
+			}
+			for _, problem := range input.validate() {
+				problems = append(problems, fmt.Sprintf("stages[%d].inputs[%d]: %s", i, j, problem))
+			}
+			if input.Key != "" && keys[input.Key] {
+				problems = append(problems, fmt.Sprintf("stages[%d].inputs[%d]: key %s is used by more than one input", i, j, input.Key))
+			}
+			keys[input.Key] = true
+		}
+		for _, reference := range s.References(stage.Command) {
+			if s.stageIndex(reference.Stage) >= i {
+				problems = append(problems, fmt.Sprintf("stages[%d]: <%s> should refer to an earlier stage", i, reference.Placeholder()))
+			}
+		}
+		if _, found := stage.Outputs[stdoutReference]; found {
+			problems = append(problems, fmt.Sprintf("stages[%d]: outputs can't be named %s, it is the stdout of the job", i, stdoutReference))
+		}
+	}
+	return problems
+}
+
+// PipelineJobs returns the jobs of every stage in stage order. The jobs of a stage are every combination
+// of the jobs of the stages it refers to, which descend from the same upstream jobs, and of its inputs values
+func (s *Spec) PipelineJobs() ([]PipelineJob, error) {
+	var jobs []PipelineJob
+	stagePositions := make(map[string][]int, len(s.Stages))
+	// the stages every stage descends from
+	ancestors := make(map[string]map[string]bool, len(s.Stages))
+	for i, stage := range s.Stages {
+		combinations, err := s.combinations(stage.Inputs)
+		if err != nil {
+			return nil, fmt.Errorf("stages[%d]: %w", i, err)
+		}
+		ancestors[stage.Name] = make(map[string]bool)
+		referenced := make(map[string]bool)
+		for _, reference := range s.References(stage.Command) {
+			referenced[reference.Stage] = true
+			ancestors[stage.Name][reference.Stage] = true
+			for ancestor := range ancestors[reference.Stage] {
+				ancestors[stage.Name][ancestor] = true
+			}
+		}
+		// a stage descending from another referenced stage already joins its jobs to the right upstream jobs
+		var parents []string
+		for _, candidate := range s.Stages[:i] {
+			if !referenced[candidate.Name] {
+				continue
+			}
+			descended := false
+			for other := range referenced {
+				if other != candidate.Name && ancestors[other][candidate.Name] {
+					descended = true
+				}
+			}
+			if !descended {
+				parents = append(parents, candidate.Name)
+			}
+		}
+		upstreams := []PipelineJob{{Upstream: map[string]int{}}}
+		for _, parent := range parents {
+			var next []PipelineJob
+			for _, upstream := range upstreams {
+				for _, position := range stagePositions[parent] {
+					if joined, ok := join(upstream, jobs[position], position); ok {
+						next = append(next, joined)
+					}
+				}
+			}
+			upstreams = next
+		}
+		commandPlaceholders := paralixutils.UniqueStrings(paralixutils.CommandPlaceholders(stage.Command))
+		for _, upstream := range upstreams {
+			for _, values := range combinations {
+				job := PipelineJob{Stage: stage.Name, Command: stage.Command, Values: make(map[string]string), Keys: append([]string(nil), upstream.Keys...), Needs: upstream.Needs, Upstream: upstream.Upstream}
+				for key, value := range upstream.Values {
+					job.Values[key] = value
+				}
+				for _, key := range commandPlaceholders {
+					_, own := values[key]
+					if _, inherited := job.Values[key]; own && !inherited {
+						job.Keys = append(job.Keys, key)
+					}
+				}
+				for key, value := range values {
+					job.Values[key] = value
+				}
+				if err := s.checkPlaceholders(jobs, job, commandPlaceholders); err != nil {
+					return nil, fmt.Errorf("stages[%d]: %w", i, err)
+				}
+				stagePositions[stage.Name] = append(stagePositions[stage.Name], len(jobs))
+				jobs = append(jobs, job)
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// join adds the upstream job at position to the partial job, unless they descend from different jobs of the same stage
+func join(partial PipelineJob, upstream PipelineJob, position int) (PipelineJob, bool) {
+	joined := PipelineJob{Values: make(map[string]string), Keys: append([]string(nil), partial.Keys...), Needs: append(append([]int(nil), partial.Needs...), position), Upstream: make(map[string]int)}
+	for stage, p := range partial.Upstream {
+		joined.Upstream[stage] = p
+	}
+	lineage := map[string]int{upstream.Stage: position}
+	for stage, p := range upstream.Upstream {
+		lineage[stage] = p
+	}
+	for stage, p := range lineage {
+		if existing, found := joined.Upstream[stage]; found && existing != p {
+			return PipelineJob{}, false
+		}
+		joined.Upstream[stage] = p
+	}
+	for key, value := range partial.Values {
+		joined.Values[key] = value
+	}
+	for _, key := range upstream.Keys {
+		if _, found := joined.Values[key]; !found {
+			joined.Keys = append(joined.Keys, key)
+		}
+		joined.Values[key] = upstream.Values[key]
+	}
+	return joined, true
+}
+
+// checkPlaceholders checks every placeholder of the command of a job is filled by a value or by an upstream job
+func (s *Spec) checkPlaceholders(jobs []PipelineJob, job PipelineJob, placeholders []string) error {
+	references := make(map[string]Reference)
+	for _, reference := range s.References(job.Command) {
+		references[reference.Placeholder()] = reference
+	}
+	for _, placeholder := range placeholders {
+		if _, found := job.Values[placeholder]; found {
+			continue
+		}
+		reference, isReference := references[placeholder]
+		if !isReference {
+			return fmt.Errorf("<%s> of the command is not filled by any input", placeholder)
+		}
+		_, isOutput := s.Stages[s.stageIndex(reference.Stage)].Outputs[reference.Name]
+		_, isValue := jobs[job.Upstream[reference.Stage]].Values[reference.Name]
+		if reference.Name != stdoutReference && !isOutput && !isValue {
+			return fmt.Errorf("<%s>: %s is neither stdout, an output nor a value of the %s stage", placeholder, reference.Name, reference.Stage)
+		}
+	}
+	return nil
+}
+
+// UpstreamValues returns the values a job refers to as <stage.name>, read from its upstream jobs.
+// stdout returns the stdout of the upstream job at a position
+func (s *Spec) UpstreamValues(jobs []PipelineJob, job PipelineJob, stdout func(position int) (string, error)) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, reference := range s.References(job.Command) {
+		position := job.Upstream[reference.Stage]
+		upstream := jobs[position]
+		switch output, isOutput := s.Stages[s.stageIndex(reference.Stage)].Outputs[reference.Name]; {
+		case reference.Name == stdoutReference:
+			content, err := stdout(position)
+			if err != nil {
+				return nil, err
+			}
+			values[reference.Placeholder()] = strings.TrimSpace(content)
+		case isOutput:
+			values[reference.Placeholder()] = paralixutils.RenderPlaceholders(output, upstream.Values)
+		default:
+			values[reference.Placeholder()] = upstream.Values[reference.Name]
+		}
+	}
+	return values, nil
+}
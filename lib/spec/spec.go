@@ -0,0 +1,287 @@
+package spec
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	paralixutils "github.com/tamirdavid/paralix/lib/paralixUtils"
+	"gopkg.in/yaml.v3"
+)
+
+var outputFormats = []string{"text", "json", "jsonl"}
+
+// Spec is a fan-out, a pipeline of fan-outs or a DAG of tasks declared in a YAML file, run with paralix run
+type Spec struct {
+	Command     string        `yaml:"command"`
+	Inputs      []Input       `yaml:"inputs"`
+	Concurrency int           `yaml:"concurrency"`
+	Retries     int           `yaml:"retries"`
+	Timeout     time.Duration `yaml:"timeout"`
+	Output      Output        `yaml:"output"`
+	JobLog      string        `yaml:"joblog"`
+	// a pipeline runs its stages and a DAG its tasks instead of a single fan-out
+	Stages []Stage `yaml:"stages"`
+	Tasks  []Task  `yaml:"tasks"`
+	// the directory of the spec file, the input files are relative to it
+	dir string
+}
+
+// Input is a source of placeholder values, exactly one of Values, Range, File and CSV is set
+type Input struct {
+	Key    string   `yaml:"key"`
+	Values []string `yaml:"values"`
+	Range  string   `yaml:"range"`
+	File   string   `yaml:"file"`
+	// every row of the CSV file fills the placeholders named by the header
+	CSV string `yaml:"csv"`
+}
+
+type Output struct {
+	Path   string `yaml:"path"`
+	Format string `yaml:"format"`
+}
+
+// ValidationError lists every problem of a spec file
+type ValidationError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s is not a valid spec:\n  %s", e.Path, strings.Join(e.Problems, "\n  "))
+}
+
+func Load(path string) (*Spec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	spec := &Spec{}
+	decoder := yaml.NewDecoder(file)
+	// unknown fields are most likely typos, like concurency
+	decoder.KnownFields(true)
+	if err := decoder.Decode(spec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, &ValidationError{Path: path, Problems: []string{"the file is empty"}}
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	spec.dir = filepath.Dir(path)
+	if spec.Output.Format == "" {
+		spec.Output.Format = "text"
+	}
+	if problems := spec.Validate(); len(problems) > 0 {
+		return nil, &ValidationError{Path: path, Problems: problems}
+	}
+	return spec, nil
+}
+
+func (s *Spec) IsPipeline() bool {
+	return len(s.Stages) > 0
+}
+
+func (s *Spec) Validate() []string {
+	var problems []string
+	switch {
+	case s.IsPipeline() && s.IsDAG():
+		problems = append(problems, "stages and tasks can't both be set")
+	case s.IsPipeline():
+		problems = append(problems, s.validateStages()...)
+	case s.IsDAG():
+		problems = append(problems, s.validateTasks()...)
+	default:
+		problems = append(problems, s.validateFanOut()...)
+	}
+	return append(problems, s.validateOptions()...)
+}
+
+func (s *Spec) validateFanOut() []string {
+	var problems []string
+	if strings.TrimSpace(s.Command) == "" {
+		problems = append(problems, "command is required")
+	}
+	if len(s.Inputs) == 0 {
+		problems = append(problems, "inputs should have at least one source of placeholder values")
+	}
+	commandPlaceholders := paralixutils.CommandPlaceholders(s.Command)
+	keys := make(map[string]bool)
+	for i, input := range s.Inputs {
+		if input.Key != "" && !paralixutils.IsStringInSlice(commandPlaceholders, input.Key) {
+			problems = append(problems, fmt.Sprintf("inputs[%d]: <%s> is missing in the command", i, input.Key))
+		}
+		for _, problem := range input.validate() {
+			problems = append(problems, fmt.Sprintf("inputs[%d]: %s", i, problem))
+		}
+		if input.Key != "" && keys[input.Key] {
+			problems = append(problems, fmt.Sprintf("inputs[%d]: key %s is used by more than one input", i, input.Key))
+		}
+		keys[input.Key] = true
+	}
+	return problems
+}
+
+func (s *Spec) validateOptions() []string {
+	var problems []string
+	if s.Concurrency < 0 {
+		problems = append(problems, "concurrency should be 0 (unlimited) or more")
+	}
+	if s.Retries < 0 {
+		problems = append(problems, "retries should be 0 or more")
+	}
+	if s.Timeout < 0 {
+		problems = append(problems, "timeout should be 0 (no timeout) or more")
+	}
+	if s.Output.Path == "" {
+		problems = append(problems, "output.path is required")
+	}
+	if !isOutputFormat(s.Output.Format) {
+		problems = append(problems, fmt.Sprintf("output.format should be one of %s but got %q", strings.Join(outputFormats, ", "), s.Output.Format))
+	}
+	return problems
+}
+
+func isOutputFormat(format string) bool {
+	for _, known := range outputFormats {
+		if format == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (i Input) validate() []string {
+	var problems []string
+	sources := 0
+	for _, set := range []bool{i.Values != nil, i.Range != "", i.File != "", i.CSV != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		problems = append(problems, "exactly one of values, range, file and csv should be set")
+	}
+	if i.CSV != "" && i.Key != "" {
+		problems = append(problems, "a csv input takes its keys from its header, key can't be set")
+	}
+	if i.CSV == "" && i.Key == "" {
+		problems = append(problems, "key is required")
+	}
+	if i.Values != nil && len(i.Values) == 0 {
+		problems = append(problems, "values is empty")
+	}
+	return problems
+}
+
+func (s *Spec) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.dir, path)
+}
+
+// Combinations returns the placeholder values of every job, every combination of the inputs values
+// is a job and the values of the last input change fastest
+func (s *Spec) Combinations() ([]map[string]string, error) {
+	combinations, err := s.combinations(s.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range paralixutils.CommandPlaceholders(s.Command) {
+		// every combination has the same keys
+		provided := len(combinations) > 0
+		if provided {
+			_, provided = combinations[0][key]
+		}
+		if !provided {
+			return nil, fmt.Errorf("<%s> of the command is not filled by any input", key)
+		}
+	}
+	return combinations, nil
+}
+
+func (s *Spec) combinations(inputs []Input) ([]map[string]string, error) {
+	combinations := []map[string]string{{}}
+	for i, input := range inputs {
+		rows, err := s.readInput(input)
+		if err != nil {
+			return nil, fmt.Errorf("inputs[%d]: %w", i, err)
+		}
+		var nextCombinations []map[string]string
+		for _, combination := range combinations {
+			for _, row := range rows {
+				values := make(map[string]string, len(combination)+len(row))
+				for key, value := range combination {
+					values[key] = value
+				}
+				for key, value := range row {
+					values[key] = value
+				}
+				nextCombinations = append(nextCombinations, values)
+			}
+		}
+		combinations = nextCombinations
+	}
+	return combinations, nil
+}
+
+func (s *Spec) readInput(input Input) ([]map[string]string, error) {
+	var values []string
+	switch {
+	case input.Values != nil:
+		values = input.Values
+	case input.Range != "":
+		expanded, err := paralixutils.ExpandRange(input.Range)
+		if err != nil {
+			return nil, err
+		}
+		if len(expanded) == 1 && expanded[0] == input.Range {
+			return nil, fmt.Errorf("range %q should look like 1..10, 001..100, 0..100..5 or a..z", input.Range)
+		}
+		values = expanded
+	case input.File != "":
+		lines, err := paralixutils.ReadLinesFromFileWithOptions(s.resolvePath(input.File), paralixutils.ReadLinesOptions{SkipBlankLines: true})
+		if err != nil {
+			return nil, err
+		}
+		values = lines
+	case input.CSV != "":
+		return readCSV(s.resolvePath(input.CSV))
+	}
+	rows := make([]map[string]string, 0, len(values))
+	for _, value := range values {
+		rows = append(rows, map[string]string{input.Key: value})
+	}
+	return rows, nil
+}
+
+func readCSV(path string) ([]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s should have a header line and at least one row", path)
+	}
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for column, key := range header {
+			row[strings.TrimSpace(key)] = record[column]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
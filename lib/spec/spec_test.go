@@ -0,0 +1,297 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeSpecFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeSpecFiles(t, map[string]string{"job.yaml": `
+command: deploy <SERVICE> <REGION>
+inputs:
+  - key: SERVICE
+    values: [api, web]
+  - key: REGION
+    file: regions.txt
+concurrency: 4
+retries: 2
+timeout: 1m30s
+output:
+  path: results.jsonl
+  format: jsonl
+joblog: deploy.joblog
+`})
+	got, err := Load(filepath.Join(dir, "job.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := &Spec{
+		Command:     "deploy <SERVICE> <REGION>",
+		Inputs:      []Input{{Key: "SERVICE", Values: []string{"api", "web"}}, {Key: "REGION", File: "regions.txt"}},
+		Concurrency: 4,
+		Retries:     2,
+		Timeout:     90 * time.Second,
+		Output:      Output{Path: "results.jsonl", Format: "jsonl"},
+		JobLog:      "deploy.joblog",
+		dir:         dir,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantProblems []string
+	}{
+		{
+			name:         "missing fields",
+			content:      "concurrency: 2\n",
+			wantProblems: []string{"command is required", "inputs should have at least one source of placeholder values", "output.path is required"},
+		},
+		{
+			name:    "invalid inputs",
+			content: "command: echo <A> <B>\ninputs:\n  - key: A\n    values: [1]\n    range: 1..2\n  - key: B\n    values: []\n  - csv: rows.csv\n    key: A\noutput:\n  path: out\n  format: csv\nretries: -1\n",
+			wantProblems: []string{
+				"inputs[0]: exactly one of values, range, file and csv should be set",
+				"inputs[1]: values is empty",
+				"inputs[2]: a csv input takes its keys from its header, key can't be set",
+				"inputs[2]: key A is used by more than one input",
+				"retries should be 0 or more",
+				`output.format should be one of text, json, jsonl but got "csv"`,
+			},
+		},
+		{
+			name:    "invalid stages",
+			content: "command: echo\noutput:\n  path: out\nstages:\n  - name: build\n    command: make <test.stdout>\n  - name: test\n    command: echo\n    outputs:\n      stdout: x\n  - name: test\n",
+			wantProblems: []string{
+				"command and inputs are set by every stage of a pipeline, not at the top level",
+				"stages[0]: <test.stdout> should refer to an earlier stage",
+				"stages[1]: outputs can't be named stdout, it is the stdout of the job",
+				"stages[2]: name test is used by more than one stage",
+				"stages[2]: command is required",
+			},
+		},
+		{
+			name:    "invalid tasks",
+			content: "output:\n  path: out\ntasks:\n  - name: fetch\n    command: fetch\n    needs: [fetch, clean]\n  - name: build\n    command: make\n    needs: [test]\n  - name: test\n    command: make test\n    needs: [build]\n",
+			wantProblems: []string{
+				"tasks[0]: fetch can't need itself",
+				"tasks[0]: needs unknown task clean",
+				"tasks build -> test -> build form a cycle",
+			},
+		},
+		{
+			name:         "stages and tasks",
+			content:      "output:\n  path: out\nstages:\n  - name: build\n    command: make\ntasks:\n  - name: test\n    command: make test\n",
+			wantProblems: []string{"stages and tasks can't both be set"},
+		},
+		{
+			name:         "unused key",
+			content:      "command: echo <A>\ninputs:\n  - key: B\n    values: [1]\noutput:\n  path: out\n",
+			wantProblems: []string{"inputs[0]: <B> is missing in the command"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSpecFiles(t, map[string]string{"job.yaml": tt.content})
+			_, err := Load(filepath.Join(dir, "job.yaml"))
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Load() error = %v, want a ValidationError", err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.wantProblems) {
+				t.Errorf("Load() problems = %q, want %q", validationErr.Problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	dir := writeSpecFiles(t, map[string]string{"job.yaml": "command: echo\nconcurency: 3\n"})
+	if _, err := Load(filepath.Join(dir, "job.yaml")); err == nil {
+		t.Errorf("Load() should fail on an unknown field")
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	dir := writeSpecFiles(t, map[string]string{
+		"hosts.txt": "h1\n\nh2\n",
+		"rows.csv":  "region,zone\nus,\"a,b\"\neu,c\n",
+	})
+	s := &Spec{
+		Command: "run <region> <zone> <HOST> <N>",
+		Inputs:  []Input{{CSV: "rows.csv"}, {Key: "HOST", File: "hosts.txt"}, {Key: "N", Range: "1..2"}},
+		dir:     dir,
+	}
+	got, err := s.Combinations()
+	if err != nil {
+		t.Fatalf("Combinations() error = %v", err)
+	}
+	if len(got) != 8 {
+		t.Fatalf("Combinations() returned %d jobs, want 8", len(got))
+	}
+	wantFirst := map[string]string{"region": "us", "zone": "a,b", "HOST": "h1", "N": "1"}
+	wantLast := map[string]string{"region": "eu", "zone": "c", "HOST": "h2", "N": "2"}
+	if !reflect.DeepEqual(got[0], wantFirst) || !reflect.DeepEqual(got[7], wantLast) {
+		t.Errorf("Combinations() first = %v, last = %v", got[0], got[7])
+	}
+	if !reflect.DeepEqual(got[1], map[string]string{"region": "us", "zone": "a,b", "HOST": "h1", "N": "2"}) {
+		t.Errorf("the last input should change fastest, second job = %v", got[1])
+	}
+}
+
+func TestCombinationsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec *Spec
+	}{
+		{name: "not a range", spec: &Spec{Command: "echo <N>", Inputs: []Input{{Key: "N", Range: "1-10"}}}},
+		{name: "missing file", spec: &Spec{Command: "echo <N>", Inputs: []Input{{Key: "N", File: "missing.txt"}}}},
+		{name: "column not in csv", spec: &Spec{Command: "echo <region> <zone>", Inputs: []Input{{CSV: "rows.csv"}}}},
+	}
+	dir := writeSpecFiles(t, map[string]string{"rows.csv": "region\nus\n"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.dir = dir
+			if _, err := tt.spec.Combinations(); err == nil {
+				t.Errorf("Combinations() should fail")
+			}
+		})
+	}
+}
+
+func TestPipelineJobs(t *testing.T) {
+	s := &Spec{Stages: []Stage{
+		{Name: "build", Command: "make <SERVICE>", Inputs: []Input{{Key: "SERVICE", Values: []string{"api", "web"}}}, Outputs: map[string]string{"artifact": "dist/<SERVICE>.tar"}},
+		{Name: "lint", Command: "lint <build.artifact>"},
+		{Name: "test", Command: "test <build.artifact> <OS>", Inputs: []Input{{Key: "OS", Values: []string{"linux", "mac"}}}},
+		{Name: "deploy", Command: "deploy <build.stdout> <test.OS> <lint.stdout>"},
+	}}
+	jobs, err := s.PipelineJobs()
+	if err != nil {
+		t.Fatalf("PipelineJobs() error = %v", err)
+	}
+	var got []string
+	for _, job := range jobs {
+		got = append(got, fmt.Sprintf("%s %v %v", job.Stage, job.Values, job.Needs))
+	}
+	want := []string{
+		"build map[SERVICE:api] []",
+		"build map[SERVICE:web] []",
+		"lint map[SERVICE:api] [0]",
+		"lint map[SERVICE:web] [1]",
+		"test map[OS:linux SERVICE:api] [0]",
+		"test map[OS:mac SERVICE:api] [0]",
+		"test map[OS:linux SERVICE:web] [1]",
+		"test map[OS:mac SERVICE:web] [1]",
+		// deploy joins every test job with the lint job of the same build job
+		"deploy map[OS:linux SERVICE:api] [2 4]",
+		"deploy map[OS:mac SERVICE:api] [2 5]",
+		"deploy map[OS:linux SERVICE:web] [3 6]",
+		"deploy map[OS:mac SERVICE:web] [3 7]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PipelineJobs() = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(jobs[11].Keys, []string{"SERVICE", "OS"}) {
+		t.Errorf("PipelineJobs() keys = %q", jobs[11].Keys)
+	}
+	stdout := func(position int) (string, error) {
+		return fmt.Sprintf("output of %d\n", position), nil
+	}
+	values, err := s.UpstreamValues(jobs, jobs[11], stdout)
+	if err != nil {
+		t.Fatalf("UpstreamValues() error = %v", err)
+	}
+	wantValues := map[string]string{"build.stdout": "output of 1", "test.OS": "mac", "lint.stdout": "output of 3"}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("UpstreamValues() = %v, want %v", values, wantValues)
+	}
+	values, _ = s.UpstreamValues(jobs, jobs[6], stdout)
+	if values["build.artifact"] != "dist/web.tar" {
+		t.Errorf("UpstreamValues() artifact = %q", values["build.artifact"])
+	}
+}
+
+func TestPipelineJobsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stages []Stage
+	}{
+		{name: "unknown upstream value", stages: []Stage{{Name: "build", Command: "make"}, {Name: "test", Command: "test <build.artifact>"}}},
+		{name: "unfilled placeholder", stages: []Stage{{Name: "build", Command: "make <SERVICE>"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Spec{Stages: tt.stages}
+			if _, err := s.PipelineJobs(); err == nil {
+				t.Errorf("PipelineJobs() should fail")
+			}
+		})
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		tasks []Task
+		want  []string
+	}{
+		{name: "no cycle", tasks: []Task{{Name: "a"}, {Name: "b", Needs: []string{"a"}}, {Name: "c", Needs: []string{"a", "b"}}}},
+		{name: "cycle", tasks: []Task{{Name: "a"}, {Name: "b", Needs: []string{"a", "d"}}, {Name: "c", Needs: []string{"b"}}, {Name: "d", Needs: []string{"c"}}}, want: []string{"b", "d", "c", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Spec{Tasks: tt.tasks}
+			if got := s.findCycle(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findCycle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskNeeds(t *testing.T) {
+	s := &Spec{Tasks: []Task{{Name: "test", Needs: []string{"build", "fetch"}}, {Name: "fetch"}, {Name: "build", Needs: []string{"fetch"}}}}
+	if got, want := s.TaskNeeds(), [][]int{{2, 1}, nil, {1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TaskNeeds() = %v, want %v", got, want)
+	}
+}
+
+func TestUpstreamOutputRenderedOnce(t *testing.T) {
+	// a value holding the placeholder of another key is used as is, whatever the map order
+	s := &Spec{Stages: []Stage{
+		{Name: "build", Command: "make <A> <B>", Inputs: []Input{{Key: "A", Values: []string{"<B>"}}, {Key: "B", Values: []string{"<A>"}}}, Outputs: map[string]string{"artifact": "<A>-<B>"}},
+		{Name: "ship", Command: "ship <build.artifact>"},
+	}}
+	jobs, err := s.PipelineJobs()
+	if err != nil {
+		t.Fatalf("PipelineJobs() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		values, err := s.UpstreamValues(jobs, jobs[1], nil)
+		if err != nil {
+			t.Fatalf("UpstreamValues() error = %v", err)
+		}
+		if values["build.artifact"] != "<B>-<A>" {
+			t.Fatalf("UpstreamValues() artifact = %q, want %q", values["build.artifact"], "<B>-<A>")
+		}
+	}
+}
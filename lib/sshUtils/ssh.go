@@ -0,0 +1,243 @@
+package sshutils
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// the master connection of a host is kept open this long after its last job, in seconds
+const controlPersistSeconds = 60
+
+type Host struct {
+	Destination string
+	Slots       int
+}
+
+func ParseHost(spec string, defaultSlots int) (Host, error) {
+	// a host is [N/][user@]hostname, like GNU parallel N is the number of jobs the host runs at once
+	spec = strings.TrimSpace(spec)
+	host := Host{Destination: spec, Slots: defaultSlots}
+	if slash := strings.Index(spec, "/"); slash >= 0 {
+		slots, err := strconv.Atoi(spec[:slash])
+		if err != nil || slots <= 0 {
+			return Host{}, fmt.Errorf("invalid number of jobs %q in host %q", spec[:slash], spec)
+		}
+		host = Host{Destination: spec[slash+1:], Slots: slots}
+	}
+	if host.Destination == "" {
+		return Host{}, fmt.Errorf("host %q has no destination", spec)
+	}
+	// a destination starting with '-' would be read by ssh as an option
+	if strings.HasPrefix(host.Destination, "-") || strings.ContainsAny(host.Destination, " \t") {
+		return Host{}, fmt.Errorf("invalid host %q", spec)
+	}
+	return host, nil
+}
+
+func ParseHostsList(list string, defaultSlots int) ([]Host, error) {
+	var hosts []Host
+	for _, spec := range strings.Split(list, ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		host, err := ParseHost(spec, defaultSlots)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts in %q", list)
+	}
+	return hosts, nil
+}
+
+func ReadHostsFile(path string, defaultSlots int) ([]Host, error) {
+	// one host per line, blank lines and # comments are ignored
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var hosts []Host
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		host, err := ParseHost(line, defaultSlots)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		hosts = append(hosts, host)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("%s has no hosts", path)
+	}
+	return hosts, nil
+}
+
+func ShellQuote(s string) string {
+	// single quotes keep everything literal, a single quote is closed, escaped and reopened
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Client runs commands on remote hosts with the ssh CLI, the jobs of a host share one master
+// connection so only the first job of every host pays for the connection setup
+type Client struct {
+	program    string
+	controlDir string
+	mu         sync.Mutex
+	usedHosts  map[string]bool
+	// hosts where a working directory was created under WorkDir
+	workHosts map[string]bool
+}
+
+func NewClient(program string) (*Client, error) {
+	controlDir, err := os.MkdirTemp("", "paralix-ssh-")
+	if err != nil {
+		return nil, err
+	}
+	return &Client{program: program, controlDir: controlDir, usedHosts: make(map[string]bool), workHosts: make(map[string]bool)}, nil
+}
+
+func (c *Client) options() []string {
+	// %C is a hash of the connection, it keeps the socket path short enough for a unix socket
+	return []string{
+		"-o", "BatchMode=yes",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + c.controlDir + "/%C",
+		"-o", fmt.Sprintf("ControlPersist=%d", controlPersistSeconds),
+	}
+}
+
+func (c *Client) Command(destination string, script string) *exec.Cmd {
+	c.mu.Lock()
+	c.usedHosts[destination] = true
+	c.mu.Unlock()
+	// ssh hands its command to the login shell of the remote user, bash -c makes the script run in bash anywhere
+	args := append(c.options(), destination, "bash -c "+ShellQuote(script))
+	return exec.Command(c.program, args...)
+}
+
+func (c *Client) WorkDir() string {
+	// relative to the home directory ssh starts in, the control directory name makes it unique per run
+	return ".paralix/" + filepath.Base(c.controlDir)
+}
+
+func (c *Client) JobDir(index int) string {
+	return fmt.Sprintf("%s/job-%d", c.WorkDir(), index)
+}
+
+func (c *Client) Upload(destination string, localPath string, remotePath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	c.mu.Lock()
+	c.workHosts[destination] = true
+	c.mu.Unlock()
+	script := fmt.Sprintf("mkdir -p -- %s && cat > %s", ShellQuote(path.Dir(remotePath)), ShellQuote(remotePath))
+	cmd := c.Command(destination, script)
+	cmd.Stdin = file
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to copy %s to %s:%s: %v %s", localPath, destination, remotePath, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (c *Client) Download(destination string, remoteDir string, paths []string, localDir string) error {
+	// the paths are packed with tar on the remote side, so they can be directories. They are quoted and
+	// taken literally, a * in a returned path is not expanded
+	quoted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		quoted = append(quoted, ShellQuote(p))
+	}
+	script := fmt.Sprintf("cd %s && tar cf - -- %s", ShellQuote(remoteDir), strings.Join(quoted, " "))
+	cmd := c.Command(destination, script)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	extractErr := extractTar(stdout, localDir)
+	// drain what is left so the remote tar isn't killed by a broken pipe
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to fetch %v from %s: %v %s", paths, destination, err, strings.TrimSpace(stderr.String()))
+	}
+	return extractErr
+}
+
+func (c *Client) Remove(destination string, remotePath string) error {
+	return c.Command(destination, "rm -rf -- "+ShellQuote(remotePath)).Run()
+}
+
+func extractTar(r io.Reader, dir string) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(header.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("refusing to extract %q outside of %s", header.Name, dir)
+		}
+		target := filepath.Join(dir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, copyErr := io.Copy(file, reader)
+			file.Close()
+			if copyErr != nil {
+				return copyErr
+			}
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for destination := range c.workHosts {
+		exec.Command(c.program, append(c.options(), destination, "rm -rf -- "+ShellQuote(c.WorkDir()))...).Run()
+	}
+	for destination := range c.usedHosts {
+		// stop the master connection, it fails harmlessly when there is none
+		args := append(c.options(), "-O", "exit", destination)
+		exec.Command(c.program, args...).Run()
+	}
+	return os.RemoveAll(c.controlDir)
+}
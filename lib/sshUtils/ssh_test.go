@@ -0,0 +1,174 @@
+package sshutils
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Host
+		wantErr bool
+	}{
+		{name: "host", spec: "web1", want: Host{Destination: "web1", Slots: 2}},
+		{name: "user and host", spec: " deploy@web1 ", want: Host{Destination: "deploy@web1", Slots: 2}},
+		{name: "jobs per host", spec: "8/deploy@web1", want: Host{Destination: "deploy@web1", Slots: 8}},
+		{name: "invalid jobs", spec: "x/web1", wantErr: true},
+		{name: "zero jobs", spec: "0/web1", wantErr: true},
+		{name: "no destination", spec: "4/", wantErr: true},
+		{name: "option", spec: "-oProxyCommand=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHost(tt.spec, 2)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseHost() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHostsList(t *testing.T) {
+	got, err := ParseHostsList("user@h1, 4/h2,", 1)
+	if err != nil {
+		t.Fatalf("ParseHostsList() error = %v", err)
+	}
+	want := []Host{{Destination: "user@h1", Slots: 1}, {Destination: "h2", Slots: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseHostsList() = %v, want %v", got, want)
+	}
+	if _, err := ParseHostsList(" , ", 1); err == nil {
+		t.Errorf("ParseHostsList() of an empty list should fail")
+	}
+}
+
+func TestReadHostsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.txt")
+	ioutil.WriteFile(path, []byte("# build machines\nuser@h1\n\n  2/h2  \n"), 0644)
+	got, err := ReadHostsFile(path, 1)
+	if err != nil {
+		t.Fatalf("ReadHostsFile() error = %v", err)
+	}
+	want := []Host{{Destination: "user@h1", Slots: 1}, {Destination: "h2", Slots: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadHostsFile() = %v, want %v", got, want)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for _, s := range []string{"plain", "it's", "$HOME `id` \"x\"", "a\nb", ""} {
+		out, err := exec.Command("bash", "-c", "printf %s "+ShellQuote(s)).Output()
+		if err != nil {
+			t.Fatalf("bash failed for %q: %v", s, err)
+		}
+		if string(out) != s {
+			t.Errorf("ShellQuote(%q) was read by bash as %q", s, out)
+		}
+	}
+}
+
+// fakeSSH writes an ssh stand-in that checks the connection reuse options and runs the remote command
+// locally, in a directory standing for the remote home directory
+func fakeSSH(t *testing.T) (string, string) {
+	home := t.TempDir()
+	path := filepath.Join(t.TempDir(), "ssh")
+	script := `#!/bin/bash
+case "$*" in *ControlMaster=auto*ControlPath=*) ;; *) echo "no connection reuse" >&2; exit 255;; esac
+if [ "${@: -3:1}" = "-O" ]; then exit 0; fi
+destination="${@: -2:1}"
+export DESTINATION="$destination"
+cd ` + ShellQuote(home) + `
+eval "${@: -1}"
+`
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake ssh: %v", err)
+	}
+	return path, home
+}
+
+func TestClientCommand(t *testing.T) {
+	program, _ := fakeSSH(t)
+	client, err := NewClient(program)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	cmd := client.Command("user@h1", `echo "$DESTINATION" 'it'"'"'s' $((1 + 1)); exit 3`)
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("Command() error = %v, want exit code 3", err)
+	}
+	if strings.TrimSpace(string(out)) != "user@h1 it's 2" {
+		t.Errorf("Command() output = %q", out)
+	}
+	controlDir := client.controlDir
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(controlDir); !os.IsNotExist(err) {
+		t.Errorf("Close() left the control directory %s", controlDir)
+	}
+}
+
+func TestClientTransfers(t *testing.T) {
+	program, home := fakeSSH(t)
+	client, err := NewClient(program)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	local := filepath.Join(t.TempDir(), "input.txt")
+	ioutil.WriteFile(local, []byte("payload"), 0644)
+	jobDir := client.JobDir(1)
+	if err := client.Upload("h1", local, jobDir+"/data/input.txt"); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(home, jobDir, "data", "input.txt")); string(content) != "payload" {
+		t.Errorf("Upload() copied %q", content)
+	}
+	client.Command("h1", "cd "+jobDir+" && mkdir out && echo result > out/result.txt").Run()
+	returnDir := t.TempDir()
+	if err := client.Download("h1", jobDir, []string{"out", "data/input.txt"}, returnDir); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	for file, want := range map[string]string{"out/result.txt": "result\n", "data/input.txt": "payload"} {
+		if content, _ := ioutil.ReadFile(filepath.Join(returnDir, file)); string(content) != want {
+			t.Errorf("Download() fetched %s = %q, want %q", file, content, want)
+		}
+	}
+	if err := client.Download("h1", jobDir, []string{"missing"}, returnDir); err == nil {
+		t.Errorf("Download() of a missing path should fail")
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, client.WorkDir())); !os.IsNotExist(err) {
+		t.Errorf("Close() left the remote work directory")
+	}
+}
+
+func TestExtractTarOutsideDirectory(t *testing.T) {
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	writer.WriteHeader(&tar.Header{Name: "../escape.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	writer.Write([]byte("x"))
+	writer.Close()
+	dir := t.TempDir()
+	if err := extractTar(&archive, dir); err == nil {
+		t.Errorf("extractTar() should refuse paths outside of the directory")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.txt")); !os.IsNotExist(err) {
+		t.Errorf("extractTar() wrote outside of the directory")
+	}
+}